@@ -22,7 +22,9 @@ import (
 	"net/http"
 	"path"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/zc2638/swag/asserts"
 )
@@ -37,6 +39,15 @@ type Object struct {
 	Format      string              `json:"format,omitempty"`
 	Required    []string            `json:"required,omitempty"`
 	Properties  map[string]Property `json:"properties,omitempty"`
+
+	// Enum, Items and AdditionalProperties describe the definitions which are not objects,
+	// e.g. the enumerations, arrays and maps of a hand-written definition
+	Enum                 []string  `json:"enum,omitempty"`
+	Items                *Items    `json:"items,omitempty"`
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
+
+	// Visibility marks the audience of the definition, it is not rendered; see API.SetDefinitionVisibility
+	Visibility Visibility `json:"-"`
 }
 
 // Property represents the property entity from the swagger definition
@@ -49,10 +60,37 @@ type Property struct {
 	Ref         string       `json:"$ref,omitempty"`
 	Example     string       `json:"example,omitempty"`
 	Items       *Items       `json:"items,omitempty"`
+	MinItems    int          `json:"minItems,omitempty"`
+	MaxItems    int          `json:"maxItems,omitempty"`
+	Minimum     *float64     `json:"minimum,omitempty"`
+	Maximum     *float64     `json:"maximum,omitempty"`
+	Default     interface{}  `json:"default,omitempty"`
+	Pattern     string       `json:"pattern,omitempty"`
+	MinLength   int          `json:"minLength,omitempty"`
+	MaxLength   int          `json:"maxLength,omitempty"`
+
+	// Required and Properties describe an inline object, e.g. from a hand-written definition;
+	// the objects described by reflection are referenced instead
+	Required   []string            `json:"required,omitempty"`
+	Properties map[string]Property `json:"properties,omitempty"`
+	// AdditionalProperties describes the values of a map, which is rendered as an object
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
+	// OneOf describes the concrete types allowed for a free-form property, see RegisterOneOf;
+	// swagger 2.0 has no way to express it, it is rendered as an extension
+	OneOf []Property `json:"x-oneOf,omitempty"`
+
+	// FreeForm reports whether the property accepts any value, i.e. it is declared as interface{} or json.RawMessage
+	FreeForm bool `json:"-"`
+
+	// Nullable reports whether the property accepts null values, i.e. it is declared as a pointer;
+	// swagger 2.0 has no way to express it, it is only rendered by OpenAPI 3
+	Nullable bool `json:"-"`
 }
 
 // Contact represents the contact entity from the swagger definition; used by Info
 type Contact struct {
+	Name  string `json:"name,omitempty"`
+	URL   string `json:"url,omitempty"`
 	Email string `json:"email,omitempty"`
 }
 
@@ -72,6 +110,19 @@ type Info struct {
 	License        License  `json:"license"`
 }
 
+// MarshalJSON omits the empty license, which is optional
+func (i Info) MarshalJSON() ([]byte, error) {
+	type info Info
+	v := struct {
+		info
+		License *License `json:"license,omitempty"`
+	}{info: info(i)}
+	if i.License != (License{}) {
+		v.License = &i.License
+	}
+	return json.Marshal(v)
+}
+
 // SecurityScheme represents a security scheme from the swagger definition.
 type SecurityScheme struct {
 	Type             string            `json:"type"`
@@ -95,13 +146,56 @@ type Endpoints struct {
 	Patch   *Endpoint `json:"patch,omitempty"`
 	Trace   *Endpoint `json:"trace,omitempty"`
 	Connect *Endpoint `json:"connect,omitempty"`
+
+	// Parameters are shared by all the endpoints of the path, an endpoint parameter with the same name
+	// and location overrides them; they are only set by Load, AddEndpoint declares the parameters per endpoint
+	Parameters []Parameter `json:"parameters,omitempty"`
 }
 
-// ServeHTTP allows endpoints to serve itself using the builtin http mux
+// ServeHTTP allows endpoints to serve itself using the builtin http mux;
+// a request whose method is not registered gets a 405 with the Allow header,
+// HEAD is served by the GET endpoint without body and OPTIONS answers with the Allow header,
+// unless the endpoints of these methods are registered
 func (e *Endpoints) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var endpoint *Endpoint
+	endpoint := e.endpoint(req.Method)
+	if endpoint == nil {
+		allow := e.allow()
+		switch {
+		case len(allow) == 0:
+			w.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodHead && e.Get != nil:
+			e.serve(e.Get, &headResponseWriter{ResponseWriter: w}, req)
+		case req.Method == http.MethodOptions:
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	e.serve(endpoint, w, req)
+}
+
+func (e *Endpoints) serve(endpoint *Endpoint, w http.ResponseWriter, req *http.Request) {
+	if endpoint.Handler == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 
-	switch req.Method {
+	h, ok := httpHandler(endpoint.Handler)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "Handler is not a standard http handler")
+		return
+	}
+	h.ServeHTTP(w, req)
+}
+
+// endpoint returns the endpoint registered for the method
+func (e *Endpoints) endpoint(method string) *Endpoint {
+	var endpoint *Endpoint
+	switch strings.ToUpper(method) {
 	case http.MethodDelete:
 		endpoint = e.Delete
 	case http.MethodHead:
@@ -121,23 +215,102 @@ func (e *Endpoints) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	case http.MethodConnect:
 		endpoint = e.Connect
 	}
+	return endpoint
+}
 
-	if endpoint == nil || endpoint.Handler == nil {
-		w.WriteHeader(http.StatusNotFound)
-		return
+// set registers the endpoint for the method, a nil endpoint removes it;
+// it reports whether the method is valid
+func (e *Endpoints) set(method string, endpoint *Endpoint) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodDelete:
+		e.Delete = endpoint
+	case http.MethodGet:
+		e.Get = endpoint
+	case http.MethodHead:
+		e.Head = endpoint
+	case http.MethodOptions:
+		e.Options = endpoint
+	case http.MethodPost:
+		e.Post = endpoint
+	case http.MethodPut:
+		e.Put = endpoint
+	case http.MethodPatch:
+		e.Patch = endpoint
+	case http.MethodTrace:
+		e.Trace = endpoint
+	case http.MethodConnect:
+		e.Connect = endpoint
+	default:
+		return false
+	}
+	return true
+}
+
+// validMethod reports whether an endpoint can be registered for the method
+func validMethod(method string) bool {
+	return (&Endpoints{}).set(method, nil)
+}
+
+// empty reports whether no endpoint is registered
+func (e *Endpoints) empty() bool {
+	empty := true
+	e.Walk(func(*Endpoint) {
+		empty = false
+	})
+	return empty
+}
+
+// allow returns the sorted methods allowed by the endpoints, including the automatic HEAD and OPTIONS;
+// it is empty if no endpoint is registered
+func (e *Endpoints) allow() []string {
+	fields := map[string]*Endpoint{
+		http.MethodDelete:  e.Delete,
+		http.MethodHead:    e.Head,
+		http.MethodGet:     e.Get,
+		http.MethodOptions: e.Options,
+		http.MethodPost:    e.Post,
+		http.MethodPut:     e.Put,
+		http.MethodPatch:   e.Patch,
+		http.MethodTrace:   e.Trace,
+		http.MethodConnect: e.Connect,
+	}
+	var methods []string
+	for method, endpoint := range fields {
+		if endpoint != nil {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	if e.Get != nil && e.Head == nil {
+		methods = append(methods, http.MethodHead)
 	}
+	if e.Options == nil {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// headResponseWriter discards the body of the response to answer a HEAD request with the GET endpoint
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
 
-	switch v := endpoint.Handler.(type) {
+// httpHandler converts the handler of an endpoint to a http.Handler if it is a standard http handler
+func httpHandler(handler interface{}) (http.Handler, bool) {
+	switch v := handler.(type) {
 	case func(w http.ResponseWriter, req *http.Request):
-		v(w, req)
-	case http.HandlerFunc:
-		v(w, req)
+		return http.HandlerFunc(v), true
 	case http.Handler:
-		v.ServeHTTP(w, req)
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = io.WriteString(w, "Handler is not a standard http handler")
+		return v, true
 	}
+	return nil, false
 }
 
 // Walk calls the specified function for each method defined within the Endpoints
@@ -171,12 +344,16 @@ func (e *Endpoints) Walk(fn func(endpoint *Endpoint)) {
 	}
 }
 
-// API provides the top level encapsulation for the swagger definition
+// API provides the top level encapsulation for the swagger definition;
+// the methods of API are safe for concurrent use, e.g. adding endpoints while the Handler serves requests,
+// as long as the exported fields are not modified directly at the same time; see Invalidate
 type API struct {
 	Swagger             string                    `json:"swagger,omitempty"`
 	Info                Info                      `json:"info"`
 	BasePath            string                    `json:"basePath,omitempty"`
 	Schemes             []string                  `json:"schemes,omitempty"`
+	Consumes            []string                  `json:"consumes,omitempty"`
+	Produces            []string                  `json:"produces,omitempty"`
 	Paths               map[string]*Endpoints     `json:"paths,omitempty"`
 	Definitions         map[string]Object         `json:"definitions,omitempty"`
 	Tags                []Tag                     `json:"tags,omitempty"`
@@ -186,24 +363,45 @@ type API struct {
 
 	tags       []Tag
 	prefixPath string
+	duplicates DuplicatePolicy
+	validating bool
+	errs       []error
+
+	operationIDs OperationIDStrategy
+	maxBodySize  int64
+
+	// mu serializes the modifications and guards published
+	mu        sync.RWMutex
+	published *API
 }
 
+// Clone returns a deep copy of the API including the paths, endpoints, definitions and the builder state
+// of WithTags and WithGroup, so that modifying the copy never affects the original; the handlers are shared
 func (a *API) Clone() *API {
-	return &API{
-		Swagger:             a.Swagger,
-		Info:                a.Info,
-		BasePath:            a.BasePath,
-		Schemes:             a.Schemes,
-		Paths:               a.Paths,
-		Definitions:         a.Definitions,
-		Tags:                a.Tags,
-		Host:                a.Host,
-		SecurityDefinitions: a.SecurityDefinitions,
-		Security:            a.Security,
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	doc := a.deepCopy()
+	if a.tags != nil {
+		doc.tags = make([]Tag, 0, len(a.tags))
+		for _, tag := range a.tags {
+			doc.tags = append(doc.tags, tag.clone())
+		}
+	}
+	doc.prefixPath = a.prefixPath
+	doc.duplicates = a.duplicates
+	doc.validating = a.validating
+	doc.operationIDs = a.operationIDs
+	doc.maxBodySize = a.maxBodySize
+	if a.errs != nil {
+		doc.errs = make([]error, len(a.errs))
+		copy(doc.errs, a.errs)
 	}
+	return doc
 }
 
-func (a *API) addPath(e *Endpoint) {
+// addPath registers the endpoint and returns the endpoint it replaces, if any
+func (a *API) addPath(e *Endpoint) *Endpoint {
 	if a.Paths == nil {
 		a.Paths = map[string]*Endpoints{}
 	}
@@ -214,28 +412,11 @@ func (a *API) addPath(e *Endpoint) {
 		a.Paths[e.Path] = v
 	}
 
-	switch strings.ToUpper(e.Method) {
-	case http.MethodDelete:
-		v.Delete = e
-	case http.MethodGet:
-		v.Get = e
-	case http.MethodHead:
-		v.Head = e
-	case http.MethodOptions:
-		v.Options = e
-	case http.MethodPost:
-		v.Post = e
-	case http.MethodPut:
-		v.Put = e
-	case http.MethodPatch:
-		v.Patch = e
-	case http.MethodTrace:
-		v.Trace = e
-	case http.MethodConnect:
-		v.Connect = e
-	default:
+	replaced := v.endpoint(e.Method)
+	if !v.set(e.Method, e) {
 		panic(fmt.Errorf("invalid method, %v", e.Method))
 	}
+	return replaced
 }
 
 func (a *API) addDefinition(e *Endpoint) {
@@ -245,37 +426,60 @@ func (a *API) addDefinition(e *Endpoint) {
 
 	if e.Parameters != nil {
 		for _, p := range e.Parameters {
-			if p.Schema != nil {
-				def := define(p.Schema.Prototype)
-				for k, v := range def {
-					if _, ok := a.Definitions[k]; !ok {
-						a.Definitions[k] = v
-					}
-				}
+			if p.Schema != nil && p.Schema.Prototype != nil {
+				a.addObjects(define(p.Schema.Prototype))
 			}
 		}
 	}
 
 	if e.Responses != nil {
 		for _, response := range e.Responses {
-			if response.Schema != nil {
-				def := define(response.Schema.Prototype)
-				for k, v := range def {
-					if _, ok := a.Definitions[k]; !ok {
-						a.Definitions[k] = v
-					}
-				}
+			if response.Schema != nil && response.Schema.Prototype != nil {
+				a.addObjects(define(response.Schema.Prototype))
 			}
 		}
 	}
 }
 
+// addObjects adds the definitions that do not exist yet and reports their unsupported Go types
+func (a *API) addObjects(def map[string]Object) {
+	names := make([]string, 0, len(def))
+	for name := range def {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := a.Definitions[name]; ok {
+			continue
+		}
+		a.Definitions[name] = def[name]
+		for _, err := range checkObject(def[name]) {
+			a.report(err)
+		}
+	}
+}
+
 func (a *API) clean() {
 	a.tags = nil
 	a.prefixPath = ""
 }
 
+// WithTags adds the tags to the API and to the endpoints of the next AddEndpoint call;
+// the pending tags are shared by all the goroutines, the next AddEndpoint call may come from another goroutine.
+//
+// Deprecated: please use Group with GroupTags, which is safe for concurrent registration
 func (a *API) WithTags(tags ...Tag) *API {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	a.addTags(tags)
+	a.tags = append(a.tags, tags...)
+	return a
+}
+
+// addTags adds the tags that are not declared yet to the API
+func (a *API) addTags(tags []Tag) {
 	for _, v := range tags {
 		exists := false
 		for _, tag := range a.Tags {
@@ -289,42 +493,124 @@ func (a *API) WithTags(tags ...Tag) *API {
 		}
 		a.Tags = append(a.Tags, v)
 	}
-	a.tags = append(a.tags, tags...)
-	return a
 }
 
+// WithTag is the same as WithTags with a single tag
+//
+// Deprecated: please use Group with GroupTags, which is safe for concurrent registration
 func (a *API) WithTag(name, description string) *API {
 	return a.WithTags(Tag{Name: name, Description: description})
 }
 
+// WithGroup sets the path prefix of the endpoints of the next AddEndpoint call;
+// the pending prefix is shared by all the goroutines, the next AddEndpoint call may come from another goroutine.
+//
+// Deprecated: please use Group, which is safe for concurrent registration
 func (a *API) WithGroup(prefixPath string) *API {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.prefixPath = prefixPath
 	return a
 }
 
 // AddEndpoint adds the specified endpoint to the API definition;
-// to generate an endpoint use ```endpoint.New```
+// to generate an endpoint use ```endpoint.New```.
+// An endpoint whose method and path are already registered is handled according to SetDuplicatePolicy,
+// an endpoint without operationId is named according to SetOperationIDStrategy and an endpoint with
+// the operationId of another endpoint fails with an error wrapping ErrDuplicateOperationID
 func (a *API) AddEndpoint(es ...*Endpoint) {
-	tags := make([]string, 0, len(a.tags))
-	for _, tag := range a.tags {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	a.addEndpoints(es, a.duplicates)
+}
+
+// addEndpoints registers the endpoints, resolving the already registered method and path with the policy
+func (a *API) addEndpoints(es []*Endpoint, policy DuplicatePolicy) {
+	defer a.clean()
+
+	a.register(es, policy, a.prefixPath, a.tags)
+}
+
+// register registers the endpoints under the path prefix with the tags,
+// resolving the already registered method and path with the policy
+func (a *API) register(es []*Endpoint, policy DuplicatePolicy, prefix string, tagList []Tag) {
+	if policy == DuplicateError {
+		if err := a.checkDuplicates(es, prefix); err != nil {
+			a.fail(err)
+			return
+		}
+	}
+
+	tags := make([]string, 0, len(tagList))
+	for _, tag := range tagList {
 		tags = append(tags, tag.Name)
 	}
 	for _, e := range es {
-		e.Path = path.Join(a.prefixPath, e.Path)
+		e.Path = path.Join(prefix, e.Path)
+		if !validMethod(e.Method) {
+			a.fail(fmt.Errorf("invalid method, %v", e.Method))
+			continue
+		}
+		if policy == DuplicateKeepFirst && a.lookup(e.Method, e.Path) != nil {
+			continue
+		}
 		e.Tags = append(e.Tags, tags...)
-		e.BuildOperationID()
-		a.addPath(e)
+		if e.OperationID == "" || e.generatedID {
+			e.OperationID = a.buildOperationID(e)
+			e.generatedID = true
+		} else if err := a.checkOperationID(e); err != nil {
+			a.fail(err)
+			continue
+		}
+		replaced := a.addPath(e)
 		a.addDefinition(e)
+		if replaced != nil && replaced != e {
+			a.pruneDefinitions(replaced)
+		}
 	}
-	a.clean()
 }
 
 // AddOptions adds some options
 func (a *API) AddOptions(options ...Option) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	// the options may call the methods of API, they are applied to a staging API
+	// that shares the state but not the lock, since the writers are already serialized
+	staging := &API{}
+	staging.assign(a)
 	for _, option := range options {
-		option(a)
-		a.clean()
+		option(staging)
+		staging.clean()
 	}
+	a.assign(staging)
+}
+
+// assign copies the fields of src except the lock and the published snapshot
+func (a *API) assign(src *API) {
+	a.Swagger = src.Swagger
+	a.Info = src.Info
+	a.BasePath = src.BasePath
+	a.Schemes = src.Schemes
+	a.Consumes = src.Consumes
+	a.Produces = src.Produces
+	a.Paths = src.Paths
+	a.Definitions = src.Definitions
+	a.Tags = src.Tags
+	a.Host = src.Host
+	a.SecurityDefinitions = src.SecurityDefinitions
+	a.Security = src.Security
+	a.tags = src.tags
+	a.prefixPath = src.prefixPath
+	a.duplicates = src.duplicates
+	a.validating = src.validating
+	a.errs = src.errs
+	a.operationIDs = src.operationIDs
+	a.maxBodySize = src.maxBodySize
 }
 
 // AddEndpointFunc adds some options
@@ -336,49 +622,169 @@ func (a *API) AddEndpointFunc(fs ...func(*API)) {
 	}
 }
 
+// AddTag adds a tag to the API
 func (a *API) AddTag(name, description string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
 	a.Tags = append(a.Tags, Tag{
 		Name:        name,
 		Description: description,
 	})
 }
 
-// Handler is a factory method that generates a http.HandlerFunc; if enableCors is true, then the handler will generate
-// cors headers
-func (a *API) Handler() http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		// customize the swagger header based on host
-		scheme := ""
-		if req.TLS != nil {
-			scheme = "https"
+// HandlerOption provides configuration options to the handler generated by API.Handler
+type HandlerOption func(o *handlerOptions)
+
+type handlerOptions struct {
+	version     Version
+	format      Format
+	compressors []compressor
+	proxy       *Proxy
+}
+
+// HandlerVersion sets the specification version rendered by the handler; defaults to VersionSwagger2
+func HandlerVersion(v Version) HandlerOption {
+	return func(o *handlerOptions) {
+		o.version = v
+	}
+}
+
+// HandlerFormat sets the format written if the request has no preference; defaults to FormatJSON
+func HandlerFormat(f Format) HandlerOption {
+	return func(o *handlerOptions) {
+		o.format = f
+	}
+}
+
+// HandlerCompression registers a content-coding negotiated with the Accept-Encoding header, e.g. "br";
+// the registered content-codings are preferred over the builtin gzip, a nil compressor disables the content-coding
+func HandlerCompression(encoding string, c Compressor) HandlerOption {
+	return func(o *handlerOptions) {
+		encoding = strings.ToLower(encoding)
+		compressors := make([]compressor, 0, len(o.compressors)+1)
+		if c != nil {
+			compressors = append(compressors, compressor{encoding: encoding, compressor: c})
 		}
-		if v := req.Header.Get("X-Forwarded-Proto"); v != "" {
-			scheme = v
+		for _, v := range o.compressors {
+			if v.encoding != encoding {
+				compressors = append(compressors, v)
+			}
 		}
-		if scheme == "" {
-			scheme = req.URL.Scheme
+		o.compressors = compressors
+	}
+}
+
+// HandlerProxy sets the Proxy deriving the host, scheme and base path of the definition
+// from the forwarded headers; by default only the X-Forwarded-Proto header is honored
+func HandlerProxy(p *Proxy) HandlerOption {
+	return func(o *handlerOptions) {
+		o.proxy = p
+	}
+}
+
+func buildHandlerOptions(opts []HandlerOption) *handlerOptions {
+	o := &handlerOptions{
+		version:     VersionSwagger2,
+		format:      FormatJSON,
+		compressors: []compressor{{encoding: "gzip", compressor: gzipCompressor}},
+		proxy:       defaultProxy,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.version == "" {
+		o.version = VersionSwagger2
+	}
+	if o.format == "" {
+		o.format = FormatJSON
+	}
+	return o
+}
+
+// Handler is a factory method that generates a http.HandlerFunc; by default the handler renders the Swagger 2.0
+// definition, use HandlerVersion to render another specification version.
+// The host, schemes and base path of the definition follow the request, including the forwarded headers of the
+// proxies trusted by HandlerProxy; by default only X-Forwarded-Proto is honored.
+// The format is negotiated with the format query parameter, e.g. ?format=yaml, or with the Accept header.
+// The serialized definition is cached until the API is modified through its methods, or until Invalidate
+// is called after modifying its exported fields directly, and it is served with a strong ETag,
+// it supports conditional requests with If-None-Match and the compression negotiated with Accept-Encoding
+func (a *API) Handler(opts ...HandlerOption) http.HandlerFunc {
+	return a.handler(nil, opts)
+}
+
+// handler serves the definition, restricted for each request to the endpoints kept by the filter if it is not nil
+func (a *API) handler(filter EndpointFilter, opts []HandlerOption) http.HandlerFunc {
+	o := buildHandlerOptions(opts)
+	cache := &documentCache{}
+	return func(w http.ResponseWriter, req *http.Request) {
+		format, err := negotiateFormat(req, o.format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		if scheme == "" {
-			scheme = "http"
+
+		// customize the swagger header based on the host and path prefix requested by the client
+		fwd := o.proxy.Resolve(req)
+		snapshot := a.snapshot()
+		key := string(format) + " " + fwd.Scheme + "://" + fwd.Host + fwd.Prefix
+		var kept map[*Endpoint]struct{}
+		if filter != nil {
+			var fingerprint string
+			kept, fingerprint = snapshot.filter(req, filter)
+			key += " " + fingerprint
 		}
-		doc := a.Clone()
-		doc.Host = req.Host
-		doc.Schemes = []string{scheme}
+		entry, err := cache.get(snapshot, key, func() ([]byte, error) {
+			// the snapshot is never mutated, a shallow copy is enough to override the top-level fields
+			doc := &API{}
+			if filter != nil {
+				doc = snapshot.view(kept)
+			} else {
+				doc.assign(snapshot)
+			}
+			doc.Host = fwd.Host
+			doc.Schemes = []string{fwd.Scheme}
+			if fwd.Prefix != "" {
+				doc.BasePath = path.Join(fwd.Prefix, doc.BasePath)
+			}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(doc)
+			var buf bytes.Buffer
+			err := Encode(&buf, doc.document(o.version), format)
+			return buf.Bytes(), err
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, "Parse API Doc exceptions")
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		entry.serve(w, req, format.ContentType(), o.compressors)
 	}
 }
 
-// Walk invoke the callback for each endpoint defined in the swagger doc
+// Walk invoke the callback for each endpoint defined in the swagger doc;
+// the callback is not invoked with the lock held, so it may call the methods of API
 func (a *API) Walk(callback func(path string, endpoint *Endpoint)) {
+	type walkEntry struct {
+		path     string
+		endpoint *Endpoint
+	}
+
+	a.mu.RLock()
+	entries := make([]walkEntry, 0, len(a.Paths))
 	for rawPath, endpoints := range a.Paths {
 		u := path.Join(a.BasePath, rawPath)
 		endpoints.Walk(func(endpoint *Endpoint) {
-			callback(u, endpoint)
+			entries = append(entries, walkEntry{path: u, endpoint: endpoint})
 		})
 	}
+	a.mu.RUnlock()
+
+	for _, entry := range entries {
+		callback(entry.path, entry.endpoint)
+	}
 }
 
 // UIPatterns returns a list of all the paths needed based on the path prefix
@@ -395,11 +801,32 @@ func UIPatterns(prefix string) []string {
 	return patterns
 }
 
-// UIHandler returns a http.Handler by the specify path prefix and the full path
-func UIHandler(prefix, uri string, autoDomain bool) http.Handler {
+// UIOption provides configuration options to the handler generated by UIHandler
+type UIOption func(o *uiOptions)
+
+type uiOptions struct {
+	proxy *Proxy
+}
+
+// UIProxy sets the Proxy deriving the path prefix, host and scheme of the spec url
+// from the forwarded headers; by default only the X-Forwarded-Proto header is honored
+func UIProxy(p *Proxy) UIOption {
+	return func(o *uiOptions) {
+		o.proxy = p
+	}
+}
+
+// UIHandler returns a http.Handler by the specify path prefix and the full path;
+// the path prefix forwarded by a proxy is prepended to the redirect and to the spec url if it is an absolute path
+func UIHandler(prefix, uri string, autoDomain bool, opts ...UIOption) http.Handler {
+	o := &uiOptions{proxy: defaultProxy}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fwd := o.proxy.Resolve(r)
 		if r.URL.Path == "" {
-			url := strings.TrimSuffix(prefix, "/") + "/"
+			url := fwd.Prefix + strings.TrimSuffix(prefix, "/") + "/"
 			http.Redirect(w, r, url, http.StatusFound)
 			return
 		}
@@ -418,21 +845,11 @@ func UIHandler(prefix, uri string, autoDomain bool) http.Handler {
 
 			// Prevent uri assignment from causing final uri exception.
 			currentURI := uri
+			if strings.HasPrefix(currentURI, "/") {
+				currentURI = fwd.Prefix + currentURI
+			}
 			if autoDomain {
-				scheme := ""
-				if r.TLS != nil {
-					scheme = "https"
-				}
-				if v := r.Header.Get("X-Forwarded-Proto"); v != "" {
-					scheme = v
-				}
-				if scheme == "" {
-					scheme = r.URL.Scheme
-				}
-				if scheme == "" {
-					scheme = "http"
-				}
-				currentURI = scheme + "://" + path.Join(r.Host, currentURI)
+				currentURI = fwd.Scheme + "://" + path.Join(fwd.Host, currentURI)
 			}
 
 			fileData = bytes.ReplaceAll(fileData, []byte(asserts.URL), []byte(currentURI))
@@ -78,6 +78,44 @@ func TestEndpoints_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestEndpoints_ServeHTTPMethodNotAllowed(t *testing.T) {
+	es := Endpoints{
+		Get: &Endpoint{
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Test", "get")
+				_, _ = io.WriteString(w, "Get")
+			},
+		},
+		Post: &Endpoint{},
+	}
+
+	w := httptest.NewRecorder()
+	es.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "http://localhost", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	es.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "http://localhost", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "get", w.Header().Get("X-Test"))
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	es.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "http://localhost", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+
+	es.Options = &Endpoint{
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	}
+	w = httptest.NewRecorder()
+	es.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "http://localhost", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Allow"))
+}
+
 func TestAPI_AddOptions(t *testing.T) {
 	type args struct {
 		options []Option
@@ -633,7 +671,7 @@ func TestAPI_AddEndpoint(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want API
+		want *API
 	}{
 		{
 			name: "normal",
@@ -658,7 +696,7 @@ func TestAPI_AddEndpoint(t *testing.T) {
 					},
 				},
 			},
-			want: API{
+			want: &API{
 				Paths: map[string]*Endpoints{
 					"/test": {
 						Get: &Endpoint{
@@ -668,6 +706,7 @@ func TestAPI_AddEndpoint(t *testing.T) {
 							Summary:     "summary",
 							Description: "desc",
 							OperationID: "getTest",
+							generatedID: true,
 							Parameters: []Parameter{
 								{
 									Schema: MakeSchema(types.String),
@@ -701,7 +740,7 @@ func TestAPI_AddEndpoint(t *testing.T) {
 					},
 				},
 			},
-			want: API{
+			want: &API{
 				Paths: map[string]*Endpoints{
 					"/test": {
 						Get: &Endpoint{
@@ -711,6 +750,7 @@ func TestAPI_AddEndpoint(t *testing.T) {
 							Summary:     "summary",
 							Description: "desc",
 							OperationID: "getTest",
+							generatedID: true,
 							Responses: map[string]Response{
 								"string": {
 									Schema: MakeSchema(types.String),
@@ -764,6 +804,7 @@ func TestAPI_WithGroup(t *testing.T) {
 				Summary:     "summary",
 				Description: "desc",
 				OperationID: "getPrefixTest",
+				generatedID: true,
 			},
 		},
 	}
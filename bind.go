@@ -0,0 +1,114 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// BindError reports the operations that could not be bound to a handler
+type BindError struct {
+	// Unknown lists the operationIds that do not exist in the API
+	Unknown []string
+	// Unbound lists the operations that have no handler,
+	// identified by operationId or by method and path if the operationId is empty
+	Unbound []string
+}
+
+func (e *BindError) Error() string {
+	parts := make([]string, 0, 2)
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown operations: "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Unbound) > 0 {
+		parts = append(parts, "unbound operations: "+strings.Join(e.Unbound, ", "))
+	}
+	return "bind failed, " + strings.Join(parts, "; ")
+}
+
+// Bind associates the handler with the endpoint identified by operationID;
+// this is the spec-first counterpart of endpoint.Handler
+func (a *API) Bind(operationID string, handler interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	e := a.operation(operationID)
+	if e == nil {
+		return &BindError{Unknown: []string{operationID}}
+	}
+	if v, ok := handler.(func(w http.ResponseWriter, r *http.Request)); ok {
+		handler = http.HandlerFunc(v)
+	}
+	e.Handler = handler
+	return nil
+}
+
+// BindAll associates each handler with the endpoint identified by its operationId,
+// and returns a *BindError if an operationId is unknown or an endpoint is left without handler
+func (a *API) BindAll(handlers map[string]interface{}) error {
+	bindErr := &BindError{}
+	for operationID, handler := range handlers {
+		if err := a.Bind(operationID, handler); err != nil {
+			bindErr.Unknown = append(bindErr.Unknown, operationID)
+		}
+	}
+	sort.Strings(bindErr.Unknown)
+	bindErr.Unbound = a.Unbound()
+
+	if len(bindErr.Unknown) > 0 || len(bindErr.Unbound) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// Unbound returns the sorted operations that have no handler,
+// identified by operationId or by method and path if the operationId is empty
+func (a *API) Unbound() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var result []string
+	for p, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if e.Handler != nil {
+				return
+			}
+			name := e.OperationID
+			if name == "" {
+				name = fmt.Sprintf("%s %s", strings.ToUpper(e.Method), p)
+			}
+			result = append(result, name)
+		})
+	}
+	sort.Strings(result)
+	return result
+}
+
+// operation returns the endpoint identified by operationID
+func (a *API) operation(operationID string) *Endpoint {
+	var result *Endpoint
+	for _, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if result == nil && e.OperationID == operationID {
+				result = e
+			}
+		})
+	}
+	return result
+}
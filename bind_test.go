@@ -0,0 +1,79 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bindTestSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets"},
+      "post": {"operationId": "addPet"}
+    },
+    "/pets/{id}": {
+      "delete": {}
+    }
+  }
+}`
+
+func TestAPI_Bind(t *testing.T) {
+	api, err := Load([]byte(bindTestSpec))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Nil(t, api.Bind("listPets", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "list")
+	}))
+	assert.Equal(t, []string{"DELETE /pets/{id}", "addPet"}, api.Unbound())
+
+	err = api.Bind("getPet", http.NotFoundHandler())
+	if assert.IsType(t, &BindError{}, err) {
+		assert.Equal(t, []string{"getPet"}, err.(*BindError).Unknown)
+	}
+
+	w := httptest.NewRecorder()
+	api.Paths["/pets"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+	assert.Equal(t, "list", w.Body.String())
+}
+
+func TestAPI_BindAll(t *testing.T) {
+	api, err := Load([]byte(bindTestSpec))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = api.BindAll(map[string]interface{}{
+		"listPets": http.NotFoundHandler(),
+		"addPet":   http.NotFoundHandler(),
+		"getPet":   http.NotFoundHandler(),
+	})
+	assert.Equal(t, &BindError{
+		Unknown: []string{"getPet"},
+		Unbound: []string{"DELETE /pets/{id}"},
+	}, err)
+	assert.EqualError(t, err, "bind failed, unknown operations: getPet; unbound operations: DELETE /pets/{id}")
+
+	api.Paths["/pets/{id}"].Delete.OperationID = "deletePet"
+	assert.Nil(t, api.BindAll(map[string]interface{}{"deletePet": http.NotFoundHandler()}))
+}
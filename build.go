@@ -0,0 +1,198 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BuildError reports all the problems found while building the API
+type BuildError struct {
+	Errors []error
+}
+
+func (e *BuildError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return "build failed, " + strings.Join(messages, "; ")
+}
+
+// SetValidating enables the validating build mode: instead of panicking, AddEndpoint and AddOptions
+// record the invalid endpoints and options and skip them, and the Go types that have no representation
+// in the definitions are recorded too instead of being ignored. The errors are returned by Err and Validate
+func (a *API) SetValidating(validating bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.validating = validating
+}
+
+// AddError records the error in the validating build mode and panics with it otherwise,
+// it allows the options to report their invalid arguments
+func (a *API) AddError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.fail(err)
+}
+
+// Err returns a *BuildError with the errors recorded in the validating build mode, or nil
+func (a *API) Err() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return buildError(a.errs)
+}
+
+// Validate returns a *BuildError with the errors recorded in the validating build mode
+// and the inconsistencies of the whole document: security schemes that are not defined
+// or not valid, and operationIds used by several endpoints; it returns nil if the API is valid
+func (a *API) Validate() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	errs := make([]error, 0, len(a.errs))
+	errs = append(errs, a.errs...)
+	errs = append(errs, a.validateSecurity()...)
+	errs = append(errs, a.validateOperationIDs()...)
+	return buildError(errs)
+}
+
+func buildError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	result := &BuildError{Errors: make([]error, len(errs))}
+	copy(result.Errors, errs)
+	return result
+}
+
+// fail records the error in the validating build mode and panics otherwise, the caller must hold the write lock
+func (a *API) fail(err error) {
+	if !a.validating {
+		panic(err)
+	}
+	a.errs = append(a.errs, err)
+}
+
+// report records the error in the validating build mode and ignores it otherwise, the caller must hold the write lock
+func (a *API) report(err error) {
+	if a.validating {
+		a.errs = append(a.errs, err)
+	}
+}
+
+// sortedPaths returns the registered paths in order, so that the errors are reported deterministically
+func (a *API) sortedPaths() []string {
+	paths := make([]string, 0, len(a.Paths))
+	for p := range a.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (a *API) validateSecurity() []error {
+	var errs []error
+
+	names := make([]string, 0, len(a.SecurityDefinitions))
+	for name := range a.SecurityDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := a.SecurityDefinitions[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("security scheme %s: %w", name, err))
+		}
+	}
+
+	check := func(security *SecurityRequirement, where string) {
+		if security == nil {
+			return
+		}
+		for _, requirement := range security.Requirements {
+			schemes := make([]string, 0, len(requirement))
+			for scheme := range requirement {
+				schemes = append(schemes, scheme)
+			}
+			sort.Strings(schemes)
+			for _, scheme := range schemes {
+				if _, ok := a.SecurityDefinitions[scheme]; !ok {
+					errs = append(errs, fmt.Errorf("unknown security scheme %s referenced by %s", scheme, where))
+				}
+			}
+		}
+	}
+	check(a.Security, "the API")
+	for _, p := range a.sortedPaths() {
+		a.Paths[p].Walk(func(e *Endpoint) {
+			check(e.Security, strings.ToUpper(e.Method)+" "+p)
+		})
+	}
+	return errs
+}
+
+func (a *API) validateOperationIDs() []error {
+	var (
+		ids        []string
+		operations = make(map[string][]string)
+	)
+	for _, p := range a.sortedPaths() {
+		a.Paths[p].Walk(func(e *Endpoint) {
+			if e.OperationID == "" {
+				return
+			}
+			if _, ok := operations[e.OperationID]; !ok {
+				ids = append(ids, e.OperationID)
+			}
+			operations[e.OperationID] = append(operations[e.OperationID], strings.ToUpper(e.Method)+" "+p)
+		})
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if len(operations[id]) > 1 {
+			errs = append(errs, fmt.Errorf("duplicate operationId %s used by %s", id, strings.Join(operations[id], ", ")))
+		}
+	}
+	return errs
+}
+
+// validate returns an error if the security scheme is not valid
+func (s SecurityScheme) validate() error {
+	switch s.Type {
+	case "basic":
+	case "apiKey":
+		if s.In != "header" && s.In != "query" {
+			return fmt.Errorf(`apiKey "in" must be one of: "header" or "query", got %q`, s.In)
+		}
+		if s.Name == "" {
+			return fmt.Errorf("apiKey name is required")
+		}
+	case "oauth2":
+		switch s.Flow {
+		case "implicit", "password", "application", "accessCode":
+		default:
+			return fmt.Errorf(`oauth2 flow must be one of: "implicit", "password", "application" or "accessCode", got %q`, s.Flow)
+		}
+	default:
+		return fmt.Errorf("invalid type %q", s.Type)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type buildPet struct {
+	Name     string            `json:"name"`
+	Callback func()            `json:"callback"`
+	Events   []chan bool       `json:"events"`
+	Handlers map[string]func() `json:"handlers"`
+}
+
+func TestAPI_Err(t *testing.T) {
+	api := New()
+	assert.Nil(t, api.Err())
+	assert.Panics(t, func() {
+		api.AddEndpoint(&Endpoint{Method: "INVALID", Path: "/pets"})
+	})
+	api.AddEndpoint(&Endpoint{
+		Method:    http.MethodGet,
+		Path:      "/pets",
+		Responses: map[string]Response{"200": {Schema: MakeSchema(buildPet{})}},
+	})
+	assert.Nil(t, api.Err(), "the reflection problems are ignored unless validating")
+
+	api = New()
+	api.SetValidating(true)
+	api.SetDuplicatePolicy(DuplicateError)
+	api.AddEndpoint(
+		&Endpoint{Method: "INVALID", Path: "/pets"},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/pets",
+			Responses: map[string]Response{"200": {Schema: MakeSchema(buildPet{})}},
+		},
+	)
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets"})
+	api.AddError(errors.New("custom"))
+	assert.NotNil(t, api.Paths["/pets"].Get)
+
+	err := api.Err()
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	name := makeName(reflect.TypeOf(buildPet{}))
+	assert.Equal(t, []string{
+		"invalid method, INVALID",
+		"unsupported type func() of property " + name + ".callback",
+		"unsupported element type chan bool of property " + name + ".events",
+		"unsupported value type func() of property " + name + ".handlers",
+		"duplicate endpoint, GET /pets",
+		"custom",
+	}, errorMessages(buildErr.Errors))
+	assert.True(t, errors.Is(buildErr.Errors[4], ErrDuplicateEndpoint))
+}
+
+func TestAPI_Validate(t *testing.T) {
+	api := New()
+	api.SecurityDefinitions = map[string]SecurityScheme{
+		"basic":  {Type: "basic"},
+		"apikey": {Type: "apiKey", Name: "X-Key", In: "cookie"},
+	}
+	api.Security = &SecurityRequirement{Requirements: []map[string][]string{{"basic": {}}}}
+	api.AddEndpoint(
+		&Endpoint{
+			Method:   http.MethodGet,
+			Path:     "/pets",
+			Security: &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"read"}}}},
+		},
+		&Endpoint{Method: http.MethodPost, Path: "/pets"},
+		&Endpoint{Method: http.MethodGet, Path: "/dogs"},
+	)
+	api.Paths["/pets"].Post.OperationID = "getPets"
+	api.Paths["/dogs"].Get.OperationID = "getPets"
+
+	err := api.Validate()
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	assert.Equal(t, []string{
+		`security scheme apikey: apiKey "in" must be one of: "header" or "query", got "cookie"`,
+		"unknown security scheme oauth referenced by GET /pets",
+		"duplicate operationId getPets used by GET /dogs, GET /pets, POST /pets",
+	}, errorMessages(buildErr.Errors))
+	assert.Nil(t, api.Err())
+
+	assert.Nil(t, New().Validate())
+}
+
+func errorMessages(errs []error) []string {
+	result := make([]string, 0, len(errs))
+	for _, err := range errs {
+		result = append(result, err.Error())
+	}
+	return result
+}
@@ -0,0 +1,203 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxCachedDocuments limits the number of serialized documents kept for a snapshot,
+// there is one for each distinct host and scheme of the requests
+const maxCachedDocuments = 64
+
+// Compressor creates a writer compressing the data written to w with a content-coding
+type Compressor func(w io.Writer) io.WriteCloser
+
+func gzipCompressor(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// documentCache keeps the serialized documents of the latest snapshot of an API
+type documentCache struct {
+	mu       sync.Mutex
+	snapshot *API
+	entries  map[string]*cachedDocument
+}
+
+// get returns the cached document of the snapshot identified by key, build is called on a cache miss
+func (c *documentCache) get(snapshot *API, key string, build func() ([]byte, error)) (*cachedDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snapshot != snapshot || len(c.entries) >= maxCachedDocuments {
+		c.snapshot = snapshot
+		c.entries = make(map[string]*cachedDocument)
+	}
+	if entry, ok := c.entries[key]; ok {
+		return entry, nil
+	}
+
+	body, err := build()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	entry := &cachedDocument{
+		body:    body,
+		etag:    hex.EncodeToString(sum[:16]),
+		encoded: make(map[string][]byte),
+	}
+	c.entries[key] = entry
+	return entry, nil
+}
+
+// cachedDocument represents a serialized document and its compressed representations
+type cachedDocument struct {
+	body []byte
+	etag string
+
+	mu      sync.Mutex
+	encoded map[string][]byte
+}
+
+// ETag returns the strong entity tag of the representation with the content-coding
+func (d *cachedDocument) ETag(encoding string) string {
+	if encoding == "" {
+		return `"` + d.etag + `"`
+	}
+	return `"` + d.etag + "-" + encoding + `"`
+}
+
+// encode returns the representation of the document with the content-coding, compressed once
+func (d *cachedDocument) encode(encoding string, compressor Compressor) []byte {
+	if encoding == "" {
+		return d.body
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if data, ok := d.encoded[encoding]; ok {
+		return data
+	}
+
+	var buf bytes.Buffer
+	w := compressor(&buf)
+	if _, err := w.Write(d.body); err != nil {
+		return nil
+	}
+	if err := w.Close(); err != nil {
+		return nil
+	}
+	d.encoded[encoding] = buf.Bytes()
+	return d.encoded[encoding]
+}
+
+// serve writes the document to w, answering with 304 if the request already holds the representation
+func (d *cachedDocument) serve(w http.ResponseWriter, req *http.Request, contentType string, compressors []compressor) {
+	encoding, compressor := negotiateEncoding(req.Header.Get("Accept-Encoding"), compressors)
+	body := d.encode(encoding, compressor)
+	if body == nil {
+		encoding = ""
+		body = d.body
+	}
+	etag := d.ETag(encoding)
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Accept-Encoding")
+	if matchETag(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+type compressor struct {
+	encoding   string
+	compressor Compressor
+}
+
+// negotiateEncoding selects the content-coding with the highest quality accepted by the client,
+// the compressors are preferred in their order on equal quality
+func negotiateEncoding(acceptEncoding string, compressors []compressor) (string, Compressor) {
+	if acceptEncoding == "" {
+		return "", nil
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q
+	}
+
+	var (
+		bestEncoding   string
+		bestCompressor Compressor
+		bestQuality    float64
+	)
+	for _, c := range compressors {
+		q, ok := accepted[c.encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if !ok || q <= bestQuality {
+			continue
+		}
+		bestEncoding, bestCompressor, bestQuality = c.encoding, c.compressor, q
+	}
+	return bestEncoding, bestCompressor
+}
+
+// matchETag reports whether the If-None-Match header matches the entity tag, using the weak comparison
+func matchETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestAPI_HandlerCache(t *testing.T) {
+	api := New()
+	handler := api.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := w.Header().Get("ETag")
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, etag)
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// another host gets another document
+	r = httptest.NewRequest(http.MethodGet, "http://example.org/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"host":"example.org"`)
+
+	// the cache is invalidated by modifications
+	api.AddTag("pet", "")
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.NotEqual(t, body, w.Body.String())
+
+	// the direct modifications of the fields are served once invalidated
+	api.Info.Title = "changed"
+	api.Invalidate()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, w.Body.String(), `"title":"changed"`)
+}
+
+func TestAPI_HandlerCompression(t *testing.T) {
+	api := New()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	api.Handler().ServeHTTP(w, r)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(w.Body)
+	if assert.Nil(t, err) {
+		data, err := io.ReadAll(gr)
+		assert.Nil(t, err)
+		assert.Contains(t, string(data), `"swagger":"2.0"`)
+	}
+
+	handler := api.Handler(HandlerCompression("br", func(w io.Writer) io.WriteCloser {
+		return nopWriteCloser{Writer: w}
+	}))
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, br")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Get("ETag"), "-br")
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	api.Handler(HandlerCompression("gzip", nil)).ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func Test_negotiateEncoding(t *testing.T) {
+	compressors := []compressor{
+		{encoding: "br", compressor: gzipCompressor},
+		{encoding: "gzip", compressor: gzipCompressor},
+	}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "identity", want: ""},
+		{accept: "gzip", want: "gzip"},
+		{accept: "gzip, br", want: "br"},
+		{accept: "gzip;q=1, br;q=0.5", want: "gzip"},
+		{accept: "br;q=0, gzip", want: "gzip"},
+		{accept: "*", want: "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, _ := negotiateEncoding(tt.accept, compressors)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_matchETag(t *testing.T) {
+	assert.False(t, matchETag("", `"a"`))
+	assert.True(t, matchETag(`"a"`, `"a"`))
+	assert.True(t, matchETag(`"b", W/"a"`, `"a"`))
+	assert.True(t, matchETag(`*`, `"a"`))
+	assert.False(t, matchETag(`"b"`, `"a"`))
+}
@@ -0,0 +1,303 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+// snapshot returns a consistent copy of the API that is never mutated afterwards,
+// it is rebuilt lazily after each modification made through the methods of API or after Invalidate
+func (a *API) snapshot() *API {
+	a.mu.RLock()
+	doc := a.published
+	a.mu.RUnlock()
+	if doc != nil {
+		return doc
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.published == nil {
+		a.published = a.deepCopy()
+	}
+	return a.published
+}
+
+// modified discards the published snapshot, the caller must hold the write lock
+func (a *API) modified() {
+	a.published = nil
+}
+
+// Invalidate discards the cached snapshot of the API served by the handlers, the views and the router;
+// it must be called after modifying the exported fields directly, e.g. api.Info.Title, once the API
+// has served a request, the methods of API invalidate the snapshot themselves
+func (a *API) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.modified()
+}
+
+// deepCopy returns a copy of the exported fields of the API that shares no mutable state with it
+func (a *API) deepCopy() *API {
+	doc := &API{
+		Swagger:  a.Swagger,
+		Info:     a.Info,
+		BasePath: a.BasePath,
+		Schemes:  copyStrings(a.Schemes),
+		Consumes: copyStrings(a.Consumes),
+		Produces: copyStrings(a.Produces),
+		Host:     a.Host,
+		Security: a.Security.clone(),
+	}
+	if a.Info.Contact != nil {
+		contact := *a.Info.Contact
+		doc.Info.Contact = &contact
+	}
+	if a.Paths != nil {
+		doc.Paths = make(map[string]*Endpoints, len(a.Paths))
+		for p, endpoints := range a.Paths {
+			doc.Paths[p] = endpoints.clone()
+		}
+	}
+	if a.Definitions != nil {
+		doc.Definitions = make(map[string]Object, len(a.Definitions))
+		for name, obj := range a.Definitions {
+			doc.Definitions[name] = obj.clone()
+		}
+	}
+	if a.Tags != nil {
+		doc.Tags = make([]Tag, 0, len(a.Tags))
+		for _, tag := range a.Tags {
+			doc.Tags = append(doc.Tags, tag.clone())
+		}
+	}
+	if a.SecurityDefinitions != nil {
+		doc.SecurityDefinitions = make(map[string]SecurityScheme, len(a.SecurityDefinitions))
+		for name, scheme := range a.SecurityDefinitions {
+			scheme.Scopes = copyStringMap(scheme.Scopes)
+			doc.SecurityDefinitions[name] = scheme
+		}
+	}
+	return doc
+}
+
+func (e *Endpoints) clone() *Endpoints {
+	if e == nil {
+		return nil
+	}
+	return &Endpoints{
+		Delete:     e.Delete.clone(),
+		Head:       e.Head.clone(),
+		Get:        e.Get.clone(),
+		Options:    e.Options.clone(),
+		Post:       e.Post.clone(),
+		Put:        e.Put.clone(),
+		Patch:      e.Patch.clone(),
+		Trace:      e.Trace.clone(),
+		Connect:    e.Connect.clone(),
+		Parameters: cloneParameters(e.Parameters),
+	}
+}
+
+// shallowCopy returns a copy of the endpoints whose endpoints are copied but share their fields,
+// e.g. to read the handlers under the lock and serve them without it
+func (e *Endpoints) shallowCopy() *Endpoints {
+	if e == nil {
+		return nil
+	}
+	copyEndpoint := func(endpoint *Endpoint) *Endpoint {
+		if endpoint == nil {
+			return nil
+		}
+		result := *endpoint
+		return &result
+	}
+	return &Endpoints{
+		Delete:     copyEndpoint(e.Delete),
+		Head:       copyEndpoint(e.Head),
+		Get:        copyEndpoint(e.Get),
+		Options:    copyEndpoint(e.Options),
+		Post:       copyEndpoint(e.Post),
+		Put:        copyEndpoint(e.Put),
+		Patch:      copyEndpoint(e.Patch),
+		Trace:      copyEndpoint(e.Trace),
+		Connect:    copyEndpoint(e.Connect),
+		Parameters: e.Parameters,
+	}
+}
+
+func (e *Endpoint) clone() *Endpoint {
+	if e == nil {
+		return nil
+	}
+	result := *e
+	result.Tags = copyStrings(e.Tags)
+	result.Produces = copyStrings(e.Produces)
+	result.Consumes = copyStrings(e.Consumes)
+	result.Security = e.Security.clone()
+	result.Parameters = cloneParameters(e.Parameters)
+	if e.Responses != nil {
+		result.Responses = make(map[string]Response, len(e.Responses))
+		for code, resp := range e.Responses {
+			result.Responses[code] = resp.clone()
+		}
+	}
+	return &result
+}
+
+func cloneParameters(parameters []Parameter) []Parameter {
+	if parameters == nil {
+		return nil
+	}
+	result := make([]Parameter, 0, len(parameters))
+	for _, p := range parameters {
+		p.Schema = p.Schema.clone()
+		p.Enum = copyStrings(p.Enum)
+		p.Minimum = copyFloat(p.Minimum)
+		p.Maximum = copyFloat(p.Maximum)
+		p.Items = p.Items.clone()
+		result = append(result, p)
+	}
+	return result
+}
+
+func (r Response) clone() Response {
+	r.Schema = r.Schema.clone()
+	if r.Headers != nil {
+		headers := make(map[string]Header, len(r.Headers))
+		for name, h := range r.Headers {
+			headers[name] = h
+		}
+		r.Headers = headers
+	}
+	return r
+}
+
+func (s *Schema) clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	result := *s
+	result.Items = s.Items.clone()
+	if s.AdditionalProperties != nil {
+		value := s.AdditionalProperties.clone()
+		result.AdditionalProperties = &value
+	}
+	return &result
+}
+
+func (i *Items) clone() *Items {
+	if i == nil {
+		return nil
+	}
+	return i.property().clone().items()
+}
+
+func (s *SecurityRequirement) clone() *SecurityRequirement {
+	if s == nil {
+		return nil
+	}
+	result := &SecurityRequirement{DisableSecurity: s.DisableSecurity}
+	if s.Requirements != nil {
+		result.Requirements = make([]map[string][]string, 0, len(s.Requirements))
+		for _, requirement := range s.Requirements {
+			m := make(map[string][]string, len(requirement))
+			for name, scopes := range requirement {
+				m[name] = copyStrings(scopes)
+			}
+			result.Requirements = append(result.Requirements, m)
+		}
+	}
+	return result
+}
+
+func (o Object) clone() Object {
+	o.Required = copyStrings(o.Required)
+	o.Enum = copyStrings(o.Enum)
+	o.Items = o.Items.clone()
+	if o.AdditionalProperties != nil {
+		value := o.AdditionalProperties.clone()
+		o.AdditionalProperties = &value
+	}
+	if o.Properties != nil {
+		properties := make(map[string]Property, len(o.Properties))
+		for name, p := range o.Properties {
+			properties[name] = p.clone()
+		}
+		o.Properties = properties
+	}
+	return o
+}
+
+func (p Property) clone() Property {
+	p.Enum = copyStrings(p.Enum)
+	p.Items = p.Items.clone()
+	p.Minimum = copyFloat(p.Minimum)
+	p.Maximum = copyFloat(p.Maximum)
+	p.Required = copyStrings(p.Required)
+	if p.Properties != nil {
+		properties := make(map[string]Property, len(p.Properties))
+		for name, v := range p.Properties {
+			properties[name] = v.clone()
+		}
+		p.Properties = properties
+	}
+	if p.AdditionalProperties != nil {
+		value := p.AdditionalProperties.clone()
+		p.AdditionalProperties = &value
+	}
+	if p.OneOf != nil {
+		oneOf := make([]Property, 0, len(p.OneOf))
+		for _, v := range p.OneOf {
+			oneOf = append(oneOf, v.clone())
+		}
+		p.OneOf = oneOf
+	}
+	return p
+}
+
+func (t Tag) clone() Tag {
+	if t.Docs != nil {
+		docs := *t.Docs
+		t.Docs = &docs
+	}
+	return t
+}
+
+func copyStrings(v []string) []string {
+	if v == nil {
+		return nil
+	}
+	result := make([]string, len(v))
+	copy(result, v)
+	return result
+}
+
+func copyFloat(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	return &result
+}
+
+func copyStringMap(v map[string]string) map[string]string {
+	if v == nil {
+		return nil
+	}
+	result := make(map[string]string, len(v))
+	for key, value := range v {
+		result[key] = value
+	}
+	return result
+}
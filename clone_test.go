@@ -0,0 +1,119 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPI_Concurrent(t *testing.T) {
+	api := New()
+	handler := api.Handler()
+	router := api.Router()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			api.AddEndpoint(&Endpoint{
+				Method:    http.MethodGet,
+				Path:      fmt.Sprintf("/pets/%d", i),
+				Handler:   http.NotFoundHandler(),
+				Responses: map[string]Response{"200": {Schema: MakeSchema(Pet{})}},
+			})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			api.AddOptions(func(api *API) {
+				api.Info.Title = "concurrent"
+				api.AddEndpoint(&Endpoint{Method: http.MethodPost, Path: fmt.Sprintf("/pets/%d", i)})
+			})
+		}(i)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets/1", nil))
+			api.Walk(func(path string, endpoint *Endpoint) {})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "concurrent", api.Info.Title)
+	assert.Len(t, api.Paths, 20)
+	for _, endpoints := range api.Paths {
+		assert.NotNil(t, endpoints.Get)
+		assert.NotNil(t, endpoints.Post)
+	}
+}
+
+func TestAPI_snapshot(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Tags: []string{"pet"}})
+
+	doc := api.snapshot()
+	assert.Same(t, doc, api.snapshot(), "the snapshot must be reused until the api is modified")
+
+	api.Paths["/pets"].Get.Tags[0] = "modified"
+	assert.Equal(t, []string{"pet"}, doc.Paths["/pets"].Get.Tags)
+
+	api.AddTag("pet", "")
+	assert.NotSame(t, doc, api.snapshot())
+	assert.Len(t, api.snapshot().Tags, 1)
+}
+
+func TestAPI_CloneDeep(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{
+		Method:     http.MethodPost,
+		Path:       "/pets",
+		Tags:       []string{"pet"},
+		Parameters: []Parameter{{In: "body", Name: "body", Schema: &Schema{Ref: "#/definitions/Pet"}}},
+		Responses: map[string]Response{
+			"200": {Description: "ok", Headers: map[string]Header{"X-Rate": {Type: "integer"}}},
+		},
+		Security: &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"write"}}}},
+	})
+	api.Definitions = map[string]Object{
+		"Pet": {Type: "object", Required: []string{"name"}, Properties: map[string]Property{"name": {Type: "string"}}},
+	}
+	api.WithGroup("/v1").WithTags(Tag{Name: "v1"})
+	want, _ := json.Marshal(api)
+
+	clone := api.Clone()
+	assert.Equal(t, "/v1", clone.prefixPath)
+	assert.Equal(t, []Tag{{Name: "v1"}}, clone.tags)
+
+	e := clone.Paths["/pets"].Post
+	e.Tags[0] = "modified"
+	e.Parameters[0].Schema.Ref = "#/definitions/Modified"
+	e.Responses["200"].Headers["X-Rate"] = Header{Type: "string"}
+	e.Security.Requirements[0]["oauth"][0] = "read"
+	clone.Definitions["Pet"].Properties["name"] = Property{Type: "integer"}
+	clone.Definitions["Pet"].Required[0] = "id"
+	clone.Paths["/dogs"] = &Endpoints{}
+	clone.tags[0].Name = "v2"
+
+	actual, _ := json.Marshal(api)
+	assert.JSONEq(t, string(want), string(actual))
+	assert.Equal(t, []Tag{{Name: "v1"}}, api.tags)
+}
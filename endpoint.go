@@ -23,17 +23,41 @@ import (
 
 // Items represents items from the swagger doc
 type Items struct {
-	Type   string `json:"type,omitempty"`
-	Format string `json:"format,omitempty"`
-	Ref    string `json:"$ref,omitempty"`
+	Type      string      `json:"type,omitempty"`
+	Format    string      `json:"format,omitempty"`
+	Enum      []string    `json:"enum,omitempty"`
+	Ref       string      `json:"$ref,omitempty"`
+	Items     *Items      `json:"items,omitempty"`
+	MinItems  int         `json:"minItems,omitempty"`
+	MaxItems  int         `json:"maxItems,omitempty"`
+	Minimum   *float64    `json:"minimum,omitempty"`
+	Maximum   *float64    `json:"maximum,omitempty"`
+	Default   interface{} `json:"default,omitempty"`
+	Pattern   string      `json:"pattern,omitempty"`
+	MinLength int         `json:"minLength,omitempty"`
+	MaxLength int         `json:"maxLength,omitempty"`
+
+	// Required and Properties describe inline objects, see Property
+	Required   []string            `json:"required,omitempty"`
+	Properties map[string]Property `json:"properties,omitempty"`
+
+	// AdditionalProperties describes the values of the maps
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
+
+	// FreeForm reports whether the items accept any value
+	FreeForm bool `json:"-"`
 }
 
 // Schema represents a schema from the swagger doc
 type Schema struct {
 	Type      string      `json:"type,omitempty"`
+	Format    string      `json:"format,omitempty"`
 	Items     *Items      `json:"items,omitempty"`
 	Ref       string      `json:"$ref,omitempty"`
 	Prototype interface{} `json:"-"`
+
+	// AdditionalProperties describes the values of a map, which is rendered as an object
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
 }
 
 // Header represents a response header
@@ -60,6 +84,14 @@ type Parameter struct {
 	Type        types.ParameterType `json:"type,omitempty"`
 	Format      string              `json:"format,omitempty"`
 	Default     string              `json:"default,omitempty"`
+	Enum        []string            `json:"enum,omitempty"`
+	Minimum     *float64            `json:"minimum,omitempty"`
+	Maximum     *float64            `json:"maximum,omitempty"`
+
+	// Items and CollectionFormat describe the values of an array parameter which is not in body,
+	// CollectionFormat is one of csv (default), ssv, tsv, pipes and multi
+	Items            *Items `json:"items,omitempty"`
+	CollectionFormat string `json:"collectionFormat,omitempty"`
 }
 
 // Endpoint represents an endpoint from the swagger doc
@@ -79,6 +111,12 @@ type Endpoint struct {
 	// swagger spec requires security to be an array of objects
 	Security   *SecurityRequirement `json:"security,omitempty"`
 	Deprecated bool                 `json:"deprecated,omitempty"`
+
+	// Visibility marks the audience of the endpoint, it is not rendered; see EndpointFilter
+	Visibility Visibility `json:"-"`
+
+	// generatedID reports whether the operationId was named by the OperationIDStrategy of the API
+	generatedID bool
 }
 
 func (e *Endpoint) BuildOperationID() {
@@ -96,3 +134,16 @@ func (s *SecurityRequirement) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(s.Requirements)
 }
+
+func (s *SecurityRequirement) UnmarshalJSON(data []byte) error {
+	var requirements []map[string][]string
+	if err := json.Unmarshal(data, &requirements); err != nil {
+		return err
+	}
+	s.Requirements = nil
+	s.DisableSecurity = requirements != nil && len(requirements) == 0
+	if len(requirements) > 0 {
+		s.Requirements = requirements
+	}
+	return nil
+}
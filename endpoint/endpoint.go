@@ -24,7 +24,8 @@ import (
 	"github.com/zc2638/swag/types"
 )
 
-// New constructs a new swagger endpoint using the fields and functional options provided
+// New constructs a new swagger endpoint using the fields and functional options provided;
+// the operationId is named by API.AddEndpoint unless it is set with OperationID
 func New(method, path string, options ...Option) *swag.Endpoint {
 	e := &swag.Endpoint{
 		Method:   strings.ToUpper(method),
@@ -32,7 +33,6 @@ func New(method, path string, options ...Option) *swag.Endpoint {
 		Produces: []string{"application/json"},
 		Consumes: []string{"application/json"},
 	}
-	e.BuildOperationID()
 
 	for _, opt := range options {
 		opt(e)
@@ -69,7 +69,8 @@ func Description(v string) Option {
 	}
 }
 
-// OperationID sets the endpoint's operationId
+// OperationID sets the endpoint's operationId, it is preserved by API.AddEndpoint,
+// which fails if another endpoint already uses it
 func OperationID(v string) Option {
 	return func(e *swag.Endpoint) {
 		e.OperationID = v
@@ -218,6 +219,18 @@ func bodyType(t reflect.Type, description string, required bool) Option {
 	return parameter(p)
 }
 
+// Enum restricts the values of the parameter with the specified name, which must be defined before;
+// e.g. endpoint.Query("status", types.String, "", true), endpoint.Enum("status", "available", "sold")
+func Enum(name string, values ...string) Option {
+	return func(e *swag.Endpoint) {
+		for i := range e.Parameters {
+			if e.Parameters[i].Name == name && e.Parameters[i].In != "body" {
+				e.Parameters[i].Enum = values
+			}
+		}
+	}
+}
+
 // Tags allows one or more tags to be associated with the endpoint
 func Tags(tags ...string) Option {
 	return func(e *swag.Endpoint) {
@@ -317,3 +330,10 @@ func Deprecated() Option {
 		e.Deprecated = true
 	}
 }
+
+// Visibility marks the audience of the endpoint, e.g. swag.VisibilityInternal; see swag.EndpointFilter
+func Visibility(v swag.Visibility) Option {
+	return func(e *swag.Endpoint) {
+		e.Visibility = v
+	}
+}
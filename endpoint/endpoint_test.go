@@ -327,3 +327,22 @@ func TestNoSecurity(t *testing.T) {
 	)
 	assert.True(t, e.Security.DisableSecurity)
 }
+
+func TestEnum(t *testing.T) {
+	e := New(
+		"get", "/",
+		Query("status", types.String, "", true),
+		BodyR(struct{}{}),
+		Enum("status", "available", "sold"),
+	)
+	assert.Equal(t, []string{"available", "sold"}, e.Parameters[0].Enum)
+	assert.Nil(t, e.Parameters[1].Enum)
+}
+
+func TestVisibility(t *testing.T) {
+	e := New(
+		"get", "/",
+		Visibility(swag.VisibilityInternal),
+	)
+	assert.Equal(t, swag.VisibilityInternal, e.Visibility)
+}
@@ -135,3 +135,33 @@ func TestSecurityRequirement_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestSecurityRequirement_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want SecurityRequirement
+	}{
+		{
+			name: "case 1: disable security",
+			data: `[]`,
+			want: SecurityRequirement{DisableSecurity: true},
+		},
+		{
+			name: "case 2: enable security",
+			data: `[{"oauth":["scope1","scope2"]}]`,
+			want: SecurityRequirement{
+				Requirements: []map[string][]string{
+					{"oauth": []string{"scope1", "scope2"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s SecurityRequirement
+			assert.Nil(t, s.UnmarshalJSON([]byte(tt.data)))
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
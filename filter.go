@@ -0,0 +1,288 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Visibility marks the audience of an endpoint or a definition, e.g. internal or partner
+type Visibility string
+
+const (
+	// VisibilityPublic is the default visibility
+	VisibilityPublic Visibility = ""
+	// VisibilityInternal marks the endpoints and definitions reserved to internal callers
+	VisibilityInternal Visibility = "internal"
+)
+
+// EndpointFilter reports whether the endpoint is rendered in a filtered view of the API;
+// the request is the one served by API.FilterHandler, it is nil for API.View. The endpoint
+// without security requirement is passed with the global security of the API, which it inherits.
+//
+// A definition marked with a visibility other than VisibilityPublic is only rendered in the views
+// including an endpoint of the same visibility; otherwise the properties referencing it are removed
+// and the endpoints referencing it directly are filtered out. The definitions, and the tags,
+// no longer referenced by the endpoints of the view are removed
+type EndpointFilter func(r *http.Request, e *Endpoint) bool
+
+// FilterVisibility keeps the endpoints with one of the visibilities
+func FilterVisibility(visibilities ...Visibility) EndpointFilter {
+	return func(_ *http.Request, e *Endpoint) bool {
+		for _, v := range visibilities {
+			if e.Visibility == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterTags keeps the endpoints with one of the tags
+func FilterTags(tags ...string) EndpointFilter {
+	return func(_ *http.Request, e *Endpoint) bool {
+		for _, tag := range e.Tags {
+			for _, v := range tags {
+				if tag == v {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// FilterScopes keeps the endpoints whose security requirement, or the global security of the API they inherit,
+// is satisfied by the scopes of the caller, i.e. one of the alternatives only requires scopes granted to the caller;
+// the endpoints without any security requirement are kept
+func FilterScopes(scopes func(r *http.Request) []string) EndpointFilter {
+	return func(r *http.Request, e *Endpoint) bool {
+		if e.Security == nil || e.Security.DisableSecurity || len(e.Security.Requirements) == 0 {
+			return true
+		}
+		var granted map[string]struct{}
+		if r != nil {
+			granted = make(map[string]struct{})
+			for _, scope := range scopes(r) {
+				granted[scope] = struct{}{}
+			}
+		}
+		for _, requirement := range e.Security.Requirements {
+			satisfied := true
+			for _, required := range requirement {
+				for _, scope := range required {
+					if _, ok := granted[scope]; !ok {
+						satisfied = false
+					}
+				}
+			}
+			if satisfied {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterAll keeps the endpoints kept by all the filters
+func FilterAll(filters ...EndpointFilter) EndpointFilter {
+	return func(r *http.Request, e *Endpoint) bool {
+		for _, filter := range filters {
+			if !filter(r, e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SetDefinitionVisibility marks the definitions of the prototypes, which are added if they do not exist yet,
+// with the visibility; see EndpointFilter
+func (a *API) SetDefinitionVisibility(v Visibility, prototypes ...interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	if a.Definitions == nil {
+		a.Definitions = make(map[string]Object)
+	}
+	for _, prototype := range prototypes {
+		name := defineObject(prototype, "").Name
+		a.addObjects(define(prototype))
+		obj := a.Definitions[name]
+		obj.Visibility = v
+		a.Definitions[name] = obj
+	}
+}
+
+// View returns a copy of the API restricted to the endpoints kept by the filter, called with a nil request
+func (a *API) View(filter EndpointFilter) *API {
+	snapshot := a.snapshot()
+	kept, _ := snapshot.filter(nil, filter)
+	return snapshot.view(kept).deepCopy()
+}
+
+// FilterHandler is the same as Handler but renders the view of the API restricted to the endpoints
+// kept by the filter for each request, e.g. based on the scopes of the caller; see EndpointFilter
+func (a *API) FilterHandler(filter EndpointFilter, opts ...HandlerOption) http.HandlerFunc {
+	return a.handler(filter, opts)
+}
+
+// filter returns the endpoints kept by the filter and a fingerprint identifying them
+func (a *API) filter(r *http.Request, filter EndpointFilter) (map[*Endpoint]struct{}, string) {
+	kept := make(map[*Endpoint]struct{})
+	var keys []string
+	for p, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			candidate := e
+			if e.Security == nil && a.Security != nil {
+				inherited := *e
+				inherited.Security = a.Security
+				candidate = &inherited
+			}
+			if filter(r, candidate) {
+				kept[e] = struct{}{}
+				keys = append(keys, e.Method+" "+p)
+			}
+		})
+	}
+	sort.Strings(keys)
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return kept, hex.EncodeToString(sum[:16])
+}
+
+// view returns a document restricted to the kept endpoints, it shares the endpoints and the definitions
+// with the API, which must not be modified
+func (a *API) view(kept map[*Endpoint]struct{}) *API {
+	audiences := make(map[Visibility]struct{})
+	for e := range kept {
+		audiences[e.Visibility] = struct{}{}
+	}
+	hidden := func(ref string) bool {
+		name, ok := refName(ref)
+		if !ok {
+			return false
+		}
+		obj, ok := a.Definitions[name]
+		if !ok || obj.Visibility == VisibilityPublic {
+			return false
+		}
+		_, ok = audiences[obj.Visibility]
+		return !ok
+	}
+
+	doc := &API{}
+	doc.assign(a)
+	doc.Paths = make(map[string]*Endpoints)
+	for p, endpoints := range a.Paths {
+		filtered := &Endpoints{Parameters: endpoints.Parameters}
+		endpoints.Walk(func(e *Endpoint) {
+			if _, ok := kept[e]; !ok {
+				return
+			}
+			for _, ref := range endpointSchemaRefs(e) {
+				if hidden(ref) {
+					return
+				}
+			}
+			filtered.set(e.Method, e)
+		})
+		if !filtered.empty() {
+			doc.Paths[p] = filtered
+		}
+	}
+
+	doc.Definitions = make(map[string]Object, len(a.Definitions))
+	for name, obj := range a.Definitions {
+		if hidden(makeRef(name)) {
+			continue
+		}
+		var removed []string
+		for propName, p := range obj.Properties {
+			for _, ref := range p.refs() {
+				if hidden(ref) {
+					removed = append(removed, propName)
+					break
+				}
+			}
+		}
+		if len(removed) > 0 {
+			obj = obj.without(removed)
+		}
+		doc.Definitions[name] = obj
+	}
+
+	used := make(map[string]struct{})
+	tags := make(map[string]struct{})
+	for _, endpoints := range doc.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			doc.endpointRefs(e, used)
+			for _, tag := range e.Tags {
+				tags[tag] = struct{}{}
+			}
+		})
+	}
+	for name := range doc.Definitions {
+		if _, ok := used[name]; !ok {
+			delete(doc.Definitions, name)
+		}
+	}
+	doc.Tags = nil
+	for _, tag := range a.Tags {
+		if _, ok := tags[tag.Name]; ok {
+			doc.Tags = append(doc.Tags, tag)
+		}
+	}
+	return doc
+}
+
+// endpointSchemaRefs returns the references held directly by the schemas of the endpoint
+func endpointSchemaRefs(e *Endpoint) []string {
+	var refs []string
+	for _, p := range e.Parameters {
+		refs = append(refs, p.Schema.refs()...)
+	}
+	for _, resp := range e.Responses {
+		refs = append(refs, resp.Schema.refs()...)
+	}
+	return refs
+}
+
+// without returns a copy of the object without the properties, the object is not modified
+func (o Object) without(names []string) Object {
+	removed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		removed[name] = struct{}{}
+	}
+	properties := make(map[string]Property, len(o.Properties))
+	for name, p := range o.Properties {
+		if _, ok := removed[name]; !ok {
+			properties[name] = p
+		}
+	}
+	var required []string
+	for _, name := range o.Required {
+		if _, ok := removed[name]; !ok {
+			required = append(required, name)
+		}
+	}
+	o.Properties = properties
+	o.Required = required
+	return o
+}
@@ -0,0 +1,236 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type filterPet struct {
+	Name  string      `json:"name"`
+	Owner filterOwner `json:"owner"`
+	Audit filterAudit `json:"audit"`
+}
+
+type filterOwner struct {
+	Name string `json:"name"`
+}
+
+type filterAudit struct {
+	By string `json:"by"`
+}
+
+type filterReport struct {
+	Count int `json:"count"`
+}
+
+func TestAPI_View(t *testing.T) {
+	api := New()
+	api.AddTag("pet", "")
+	api.AddTag("owner", "")
+	api.AddTag("admin", "")
+	api.SetDefinitionVisibility(VisibilityInternal, filterAudit{})
+	api.AddEndpoint(
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/pets",
+			Tags:      []string{"pet"},
+			Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema([]filterPet{})}},
+		},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/owners",
+			Tags:      []string{"owner"},
+			Security:  &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"read"}}}},
+			Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema(filterOwner{})}},
+		},
+		&Endpoint{
+			Method:     http.MethodPost,
+			Path:       "/admin/reports",
+			Tags:       []string{"admin"},
+			Parameters: []Parameter{{In: "body", Name: "body", Schema: MakeSchema(filterReport{})}},
+			Visibility: VisibilityInternal,
+		},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/audits",
+			Tags:      []string{"pet"},
+			Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema(filterAudit{})}},
+		},
+	)
+	pet := makeName(reflect.TypeOf(filterPet{}))
+	owner := makeName(reflect.TypeOf(filterOwner{}))
+	audit := makeName(reflect.TypeOf(filterAudit{}))
+	report := makeName(reflect.TypeOf(filterReport{}))
+
+	public := api.View(FilterVisibility(VisibilityPublic))
+	assert.Contains(t, public.Paths, "/pets")
+	assert.Contains(t, public.Paths, "/owners")
+	assert.NotContains(t, public.Paths, "/admin/reports")
+	assert.NotContains(t, public.Paths, "/audits", "the endpoint returns an internal definition")
+	assert.Contains(t, public.Definitions, pet)
+	assert.Contains(t, public.Definitions, owner)
+	assert.NotContains(t, public.Definitions, audit)
+	assert.NotContains(t, public.Definitions, report)
+	assert.NotContains(t, public.Definitions[pet].Properties, "audit")
+	assert.NotContains(t, public.Definitions[pet].Required, "audit")
+	assert.Equal(t, []Tag{{Name: "pet"}, {Name: "owner"}}, public.Tags)
+
+	// the API is not modified
+	assert.Contains(t, api.Definitions[pet].Properties, "audit")
+	assert.Contains(t, api.Paths, "/audits")
+
+	internal := api.View(FilterVisibility(VisibilityPublic, VisibilityInternal))
+	assert.Len(t, internal.Paths, 4)
+	assert.Len(t, internal.Definitions, 4)
+	assert.Contains(t, internal.Definitions[pet].Properties, "audit")
+	assert.Len(t, internal.Tags, 3)
+
+	partner := api.View(FilterTags("owner"))
+	assert.Len(t, partner.Paths, 1)
+	assert.Contains(t, partner.Paths, "/owners")
+	assert.Equal(t, []string{owner}, keys(partner.Definitions))
+	assert.Equal(t, []Tag{{Name: "owner"}}, partner.Tags)
+
+	none := api.View(FilterAll(FilterTags("owner"), FilterVisibility(VisibilityInternal)))
+	assert.Empty(t, none.Paths)
+	assert.Empty(t, none.Definitions)
+}
+
+func TestAPI_FilterHandler(t *testing.T) {
+	api := New()
+	api.SetDefinitionVisibility(VisibilityInternal, filterAudit{})
+	api.AddEndpoint(
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/pets",
+			Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema([]filterPet{})}},
+		},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/owners",
+			Security:  &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"read"}}}},
+			Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema(filterOwner{})}},
+		},
+	)
+	handler := api.FilterHandler(FilterAll(
+		FilterVisibility(VisibilityPublic),
+		FilterScopes(func(r *http.Request) []string {
+			return strings.Fields(r.Header.Get("X-Scopes"))
+		}),
+	))
+
+	get := func(scopes string) (*API, string) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Scopes", scopes)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var doc API
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		return &doc, w.Header().Get("ETag")
+	}
+
+	anonymous, etag := get("")
+	assert.Contains(t, anonymous.Paths, "/pets")
+	assert.NotContains(t, anonymous.Paths, "/owners")
+	assert.NotContains(t, anonymous.Definitions, makeName(reflect.TypeOf(filterAudit{})))
+
+	reader, readerETag := get("write read")
+	assert.Contains(t, reader.Paths, "/pets")
+	assert.Contains(t, reader.Paths, "/owners")
+	assert.NotEqual(t, etag, readerETag)
+
+	_, again := get("")
+	assert.Equal(t, etag, again)
+}
+
+func TestFilterScopes(t *testing.T) {
+	filter := FilterScopes(func(r *http.Request) []string {
+		return []string{"read"}
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	requirement := func(requirements ...map[string][]string) *Endpoint {
+		return &Endpoint{Security: &SecurityRequirement{Requirements: requirements}}
+	}
+
+	assert.True(t, filter(r, &Endpoint{}))
+	assert.True(t, filter(r, &Endpoint{Security: &SecurityRequirement{DisableSecurity: true}}))
+	assert.True(t, filter(r, requirement(map[string][]string{"apiKey": nil})))
+	assert.True(t, filter(r, requirement(map[string][]string{"oauth": {"read"}})))
+	assert.False(t, filter(r, requirement(map[string][]string{"oauth": {"read", "write"}})))
+	assert.True(t, filter(r, requirement(
+		map[string][]string{"oauth": {"write"}},
+		map[string][]string{"oauth": {"read"}},
+	)))
+	assert.False(t, filter(nil, requirement(map[string][]string{"oauth": {"read"}})))
+}
+
+func TestAPI_FilterScopesGlobalSecurity(t *testing.T) {
+	api := New()
+	api.Security = &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"write"}}}}
+	api.AddEndpoint(
+		&Endpoint{Method: http.MethodGet, Path: "/pets"},
+		&Endpoint{Method: http.MethodGet, Path: "/public", Security: &SecurityRequirement{DisableSecurity: true}},
+		&Endpoint{
+			Method:   http.MethodGet,
+			Path:     "/owners",
+			Security: &SecurityRequirement{Requirements: []map[string][]string{{"oauth": {"read"}}}},
+		},
+	)
+	handler := api.FilterHandler(FilterScopes(func(r *http.Request) []string {
+		return strings.Split(r.Header.Get("X-Scopes"), ",")
+	}))
+
+	tests := []struct {
+		scopes string
+		want   []string
+	}{
+		{scopes: "read", want: []string{"/owners", "/public"}},
+		{scopes: "read,write", want: []string{"/owners", "/pets", "/public"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scopes, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Scopes", tt.scopes)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			var doc API
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+			paths := make([]string, 0, len(doc.Paths))
+			for p := range doc.Paths {
+				paths = append(paths, p)
+			}
+			assert.ElementsMatch(t, tt.want, paths)
+		})
+	}
+}
+
+func keys(m map[string]Object) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
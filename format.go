@@ -0,0 +1,174 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format represents the serialization format of a document
+type Format string
+
+const (
+	// FormatJSON is the compact JSON format
+	FormatJSON Format = "json"
+	// FormatCanonicalJSON is the indented JSON format with sorted keys, suitable for diffs
+	FormatCanonicalJSON Format = "canonical"
+	// FormatYAML is the YAML format with sorted keys
+	FormatYAML Format = "yaml"
+)
+
+// ParseFormat returns the Format named by s, "yml" and "pretty" are accepted as aliases
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		return FormatJSON, nil
+	case "canonical", "pretty":
+		return FormatCanonicalJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unsupported format: %s", s)
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	if f == FormatYAML {
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+// Encode writes the serialization of v in the format to w
+func Encode(w io.Writer, v interface{}, format Format) error {
+	if format == FormatJSON || format == "" {
+		return json.NewEncoder(w).Encode(v)
+	}
+
+	// go through the JSON representation so that the json tags and marshalers are honored,
+	// the generic value has its keys sorted by the encoders
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatCanonicalJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(generic)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(yamlValue(generic)); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+// WriteTo writes the Swagger 2.0 definition of the API in the format to w, e.g. to export it at build time;
+// use Encode to write the documents of the other specification versions
+func (a *API) WriteTo(w io.Writer, format Format) error {
+	return Encode(w, a.snapshot().document(VersionSwagger2), format)
+}
+
+// yamlValue converts the numbers of a generic JSON value, which would be written as strings otherwise
+func yamlValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = yamlValue(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = yamlValue(item)
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// negotiateFormat returns the format requested by the format query parameter or by the Accept header,
+// def is used if the request has no preference
+func negotiateFormat(req *http.Request, def Format) (Format, error) {
+	if v := req.URL.Query().Get("format"); v != "" {
+		return ParseFormat(v)
+	}
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return def, nil
+	}
+	var (
+		best        Format
+		bestQuality float64
+	)
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		var format Format
+		switch strings.ToLower(strings.TrimSpace(fields[0])) {
+		case "application/json":
+			format = FormatJSON
+			if def == FormatCanonicalJSON {
+				format = def
+			}
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			format = FormatYAML
+		case "*/*", "application/*":
+			format = def
+		default:
+			continue
+		}
+		if q > bestQuality {
+			best, bestQuality = format, q
+		}
+	}
+	if best == "" {
+		return def, nil
+	}
+	return best, nil
+}
@@ -0,0 +1,97 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	v := map[string]interface{}{
+		"b": 1,
+		"a": []interface{}{"x", 1.5},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, Encode(&buf, v, FormatJSON))
+	assert.Equal(t, `{"a":["x",1.5],"b":1}`+"\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, Encode(&buf, v, FormatCanonicalJSON))
+	assert.Equal(t, "{\n  \"a\": [\n    \"x\",\n    1.5\n  ],\n  \"b\": 1\n}\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, Encode(&buf, v, FormatYAML))
+	assert.Equal(t, "a:\n  - x\n  - 1.5\nb: 1\n", buf.String())
+
+	assert.NotNil(t, Encode(&buf, v, Format("xml")))
+}
+
+func TestAPI_WriteTo(t *testing.T) {
+	api := New()
+	api.Info.Version = "1.0"
+
+	var buf bytes.Buffer
+	assert.Nil(t, api.WriteTo(&buf, FormatYAML))
+	assert.Contains(t, buf.String(), "swagger: \"2.0\"\n")
+	assert.Contains(t, buf.String(), "  version: \"1.0\"\n")
+}
+
+func TestAPI_HandlerFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		wantCode    int
+		contentType string
+		prefix      string
+	}{
+		{name: "default", target: "/", contentType: "application/json", prefix: `{"swagger":"2.0"`},
+		{name: "query yaml", target: "/?format=yaml", contentType: "application/yaml", prefix: "basePath: /\n"},
+		{name: "query canonical", target: "/?format=canonical", contentType: "application/json", prefix: "{\n  \"basePath\": \"/\""},
+		{name: "query invalid", target: "/?format=xml", wantCode: http.StatusBadRequest},
+		{name: "accept yaml", target: "/", accept: "text/yaml", contentType: "application/yaml", prefix: "basePath: /\n"},
+		{name: "accept quality", target: "/", accept: "application/yaml;q=0.5, application/json", contentType: "application/json"},
+		{name: "accept any", target: "/", accept: "text/html, */*;q=0.8", contentType: "application/json"},
+	}
+	handler := New().Handler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, w.Code)
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept")
+			assert.Contains(t, w.Body.String(), tt.prefix)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	New().Handler(HandlerFormat(FormatYAML)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+}
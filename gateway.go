@@ -0,0 +1,339 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GatewaySource describes a downstream service whose definition is aggregated by a Gateway
+type GatewaySource struct {
+	// Name identifies the service, it tags its endpoints and namespaces its definitions and operationIds,
+	// e.g. users.Pet and users.getHealth
+	Name string
+	// URL of the swagger 2.0 json definition of the service, e.g. served by API.Handler;
+	// it is parsed by Load, a definition Load rejects is reported in the status of the source
+	URL string
+	// Prefix is the path prefix of the endpoints of the service in the aggregated definition,
+	// the base path of the downstream definition is ignored
+	Prefix string
+}
+
+// GatewayStatus reports the state of a source of a Gateway
+type GatewayStatus struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// LastAttempt is the time of the last fetch
+	LastAttempt time.Time `json:"lastAttempt"`
+	// LastSuccess is the time of the last successful fetch, the definition fetched then is still served
+	LastSuccess time.Time `json:"lastSuccess"`
+	// Error is the error of the last fetch if it failed, i.e. the source is unreachable or invalid
+	Error string `json:"error,omitempty"`
+	// Stale reports whether the last successful fetch is older than the stale duration of the gateway,
+	// or whether the definition has never been fetched
+	Stale bool `json:"stale"`
+}
+
+// GatewayOption provides configuration options to a Gateway
+type GatewayOption func(g *Gateway)
+
+// GatewayClient sets the client fetching the definitions; defaults to a client with a 10 seconds timeout
+func GatewayClient(client *http.Client) GatewayOption {
+	return func(g *Gateway) {
+		g.client = client
+	}
+}
+
+// GatewayInterval sets the interval between the refreshes made by Gateway.Run; defaults to 1 minute
+func GatewayInterval(d time.Duration) GatewayOption {
+	return func(g *Gateway) {
+		g.interval = d
+	}
+}
+
+// GatewayStaleAfter sets the age after which a definition is reported as stale; defaults to 3 intervals
+func GatewayStaleAfter(d time.Duration) GatewayOption {
+	return func(g *Gateway) {
+		g.staleAfter = d
+	}
+}
+
+// Gateway aggregates the definitions of downstream services into a single API,
+// the definitions are fetched by Refresh, periodically with Run, and served by Handler
+type Gateway struct {
+	base       *API
+	sources    []GatewaySource
+	client     *http.Client
+	interval   time.Duration
+	staleAfter time.Duration
+
+	// api is the aggregated API, it is replaced in place so that its handlers serve the latest definition
+	api *API
+
+	mu       sync.Mutex
+	specs    []*API
+	statuses []GatewayStatus
+}
+
+// NewGateway returns a Gateway aggregating the sources, the base API provides the info, host,
+// base path, schemes and security of the aggregated definition, it may be nil
+func NewGateway(base *API, sources []GatewaySource, opts ...GatewayOption) *Gateway {
+	if base == nil {
+		base = New()
+	}
+	g := &Gateway{
+		base:     base.Clone(),
+		sources:  sources,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: time.Minute,
+		specs:    make([]*API, len(sources)),
+		statuses: make([]GatewayStatus, len(sources)),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.staleAfter <= 0 {
+		g.staleAfter = 3 * g.interval
+	}
+	for i, source := range sources {
+		g.statuses[i] = GatewayStatus{Name: source.Name, URL: source.URL}
+	}
+	g.api = g.base.Clone()
+	return g
+}
+
+// API returns the aggregated API, it is updated in place by Refresh
+func (g *Gateway) API() *API {
+	return g.api
+}
+
+// Handler returns a http.Handler serving the aggregated definition, see API.Handler
+func (g *Gateway) Handler(opts ...HandlerOption) http.Handler {
+	return g.api.Handler(opts...)
+}
+
+// Status returns the state of each source, in the order of the sources
+func (g *Gateway) Status() []GatewayStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make([]GatewayStatus, len(g.statuses))
+	copy(result, g.statuses)
+	for i := range result {
+		result[i].Stale = result[i].LastSuccess.IsZero() || time.Since(result[i].LastSuccess) > g.staleAfter
+	}
+	return result
+}
+
+// StatusHandler returns a http.Handler writing the Status as json,
+// with 503 if a source is unreachable or stale and 200 otherwise
+func (g *Gateway) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		statuses := g.Status()
+		code := http.StatusOK
+		for _, status := range statuses {
+			if status.Error != "" || status.Stale {
+				code = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(statuses)
+	})
+}
+
+// Run refreshes the aggregated definition immediately and then at each interval, until the context is done
+func (g *Gateway) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		_ = g.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the definitions of all the sources concurrently and rebuilds the aggregated API;
+// the sources that cannot be fetched keep their last definition. It returns an error describing
+// the sources that failed and the conflicts between the definitions, see MergeError
+func (g *Gateway) Refresh(ctx context.Context) error {
+	type result struct {
+		spec *API
+		err  error
+		at   time.Time
+	}
+	results := make([]result, len(g.sources))
+	var wg sync.WaitGroup
+	for i, source := range g.sources {
+		wg.Add(1)
+		go func(i int, source GatewaySource) {
+			defer wg.Done()
+			spec, err := g.fetch(ctx, source)
+			results[i] = result{spec: spec, err: err, at: time.Now()}
+		}(i, source)
+	}
+	wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var messages []string
+	for i, r := range results {
+		status := &g.statuses[i]
+		status.LastAttempt = r.at
+		if r.err != nil {
+			status.Error = r.err.Error()
+			messages = append(messages, fmt.Sprintf("source %s: %v", g.sources[i].Name, r.err))
+			continue
+		}
+		status.Error = ""
+		status.LastSuccess = r.at
+		g.specs[i] = r.spec
+	}
+
+	doc, conflicts := g.build()
+	if len(conflicts) > 0 {
+		messages = append(messages, (&MergeError{Conflicts: conflicts}).Error())
+	}
+
+	g.api.mu.Lock()
+	g.api.assign(doc)
+	g.api.modified()
+	g.api.mu.Unlock()
+
+	if len(messages) > 0 {
+		return errors.New("refresh failed, " + strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+func (g *Gateway) fetch(ctx context.Context, source GatewaySource) (*API, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return LoadReader(resp.Body)
+}
+
+// build aggregates the last definitions of the sources, the caller must hold g.mu
+func (g *Gateway) build() (*API, []string) {
+	doc := g.base.Clone()
+	var conflicts []string
+	for i, source := range g.sources {
+		if g.specs[i] == nil {
+			continue
+		}
+		spec := g.specs[i].Clone()
+		spec.namespace(source.Name)
+		conflicts = append(conflicts, doc.mount(source.Prefix, spec)...)
+	}
+	return doc, conflicts
+}
+
+// namespace prefixes the definitions with the name and tags the endpoints with it
+func (a *API) namespace(name string) {
+	rename := func(ref string) string {
+		const prefix = "#/definitions/"
+		if !strings.HasPrefix(ref, prefix) {
+			return ref
+		}
+		return makeRef(name + "." + strings.TrimPrefix(ref, prefix))
+	}
+	renameSchema := func(s *Schema) {
+		if s == nil {
+			return
+		}
+		s.Ref = rename(s.Ref)
+		if s.Items != nil {
+			s.Items = s.Items.property().renameRefs(rename).items()
+		}
+		if s.AdditionalProperties != nil {
+			*s.AdditionalProperties = s.AdditionalProperties.renameRefs(rename)
+		}
+	}
+
+	definitions := make(map[string]Object, len(a.Definitions))
+	for defName, obj := range a.Definitions {
+		for propName, p := range obj.Properties {
+			obj.Properties[propName] = p.renameRefs(rename)
+		}
+		if obj.Items != nil {
+			obj.Items = obj.Items.property().renameRefs(rename).items()
+		}
+		if obj.AdditionalProperties != nil {
+			*obj.AdditionalProperties = obj.AdditionalProperties.renameRefs(rename)
+		}
+		definitions[name+"."+defName] = obj
+	}
+	a.Definitions = definitions
+
+	for _, endpoints := range a.Paths {
+		for i := range endpoints.Parameters {
+			renameSchema(endpoints.Parameters[i].Schema)
+		}
+		endpoints.Walk(func(e *Endpoint) {
+			for i := range e.Parameters {
+				renameSchema(e.Parameters[i].Schema)
+			}
+			for code, resp := range e.Responses {
+				renameSchema(resp.Schema)
+				e.Responses[code] = resp
+			}
+			e.Tags = append([]string{name}, e.Tags...)
+			if e.OperationID != "" {
+				e.OperationID = name + "." + e.OperationID
+			}
+		})
+	}
+	a.Tags = append([]Tag{{Name: name}}, a.Tags...)
+}
+
+// renameRefs returns the property with its references renamed, the items, values and properties are modified in place
+func (p Property) renameRefs(rename func(ref string) string) Property {
+	p.Ref = rename(p.Ref)
+	if p.Items != nil {
+		p.Items = p.Items.property().renameRefs(rename).items()
+	}
+	if p.AdditionalProperties != nil {
+		*p.AdditionalProperties = p.AdditionalProperties.renameRefs(rename)
+	}
+	for i, v := range p.OneOf {
+		p.OneOf[i] = v.renameRefs(rename)
+	}
+	for name, v := range p.Properties {
+		p.Properties[name] = v.renameRefs(rename)
+	}
+	return p
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gatewayUser struct {
+	Name    string         `json:"name"`
+	Address gatewayAddress `json:"address"`
+}
+
+type gatewayAddress struct {
+	City string `json:"city"`
+}
+
+func TestGateway(t *testing.T) {
+	users := New()
+	users.AddEndpoint(&Endpoint{
+		Method:    http.MethodGet,
+		Path:      "/users",
+		Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema([]gatewayUser{})}},
+	}, &Endpoint{Method: http.MethodGet, Path: "/health"})
+	usersServer := httptest.NewServer(users.Handler())
+	defer usersServer.Close()
+
+	orders := New()
+	orders.AddEndpoint(&Endpoint{
+		Method:     http.MethodPost,
+		Path:       "/orders",
+		Parameters: []Parameter{{In: "body", Name: "body", Schema: MakeSchema(gatewayUser{})}},
+	}, &Endpoint{Method: http.MethodGet, Path: "/health"})
+	ordersServer := httptest.NewServer(orders.Handler())
+
+	base := New()
+	base.Info.Title = "gateway"
+	g := NewGateway(base, []GatewaySource{
+		{Name: "users", URL: usersServer.URL, Prefix: "/users-service"},
+		{Name: "orders", URL: ordersServer.URL + "/?format=json", Prefix: "/orders-service"},
+		{Name: "missing", URL: usersServer.URL + "/missing", Prefix: "/missing"},
+	}, GatewayStaleAfter(time.Hour))
+
+	assert.Nil(t, g.Refresh(context.Background()))
+
+	api := g.API()
+	assert.Nil(t, api.Validate())
+	assert.Equal(t, "users.getHealth", api.Paths["/users-service/health"].Get.OperationID)
+	assert.Equal(t, "orders.getHealth", api.Paths["/orders-service/health"].Get.OperationID)
+	// the users server serves the definition on every path
+	assert.Equal(t, "missing.getUsers", api.Paths["/missing/users"].Get.OperationID)
+	assert.Equal(t, "gateway", api.Info.Title)
+	user := makeName(reflect.TypeOf(gatewayUser{}))
+	address := makeName(reflect.TypeOf(gatewayAddress{}))
+
+	e := api.Paths["/users-service/users"].Get
+	if assert.NotNil(t, e) {
+		assert.Equal(t, []string{"users"}, e.Tags)
+		assert.Equal(t, makeRef("users."+user), e.Responses["200"].Schema.Items.Ref)
+	}
+	e = api.Paths["/orders-service/orders"].Post
+	if assert.NotNil(t, e) {
+		assert.Equal(t, []string{"orders"}, e.Tags)
+		assert.Equal(t, makeRef("orders."+user), e.Parameters[0].Schema.Ref)
+	}
+	assert.Equal(t, makeRef("users."+address), api.Definitions["users."+user].Properties["address"].Ref)
+	assert.Contains(t, api.Definitions, "orders."+address)
+
+	w := httptest.NewRecorder()
+	g.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, w.Body.String(), `"/orders-service/orders"`)
+	etag := w.Header().Get("ETag")
+
+	// the unreachable source keeps its last definition and is reported
+	ordersServer.Close()
+	g.sources = g.sources[:2]
+	g.specs = g.specs[:2]
+	g.statuses = g.statuses[:2]
+	err := g.Refresh(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "refresh failed, source orders: "))
+	assert.NotNil(t, api.Paths["/orders-service/orders"])
+
+	statuses := g.Status()
+	assert.Equal(t, "", statuses[0].Error)
+	assert.False(t, statuses[0].Stale)
+	assert.NotEqual(t, "", statuses[1].Error)
+	assert.False(t, statuses[1].LastSuccess.IsZero())
+
+	w = httptest.NewRecorder()
+	g.StatusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var reported []GatewayStatus
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reported))
+	assert.Len(t, reported, 2)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	g.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code, "the definition is rebuilt by each refresh")
+}
+
+func TestGateway_Stale(t *testing.T) {
+	g := NewGateway(nil, []GatewaySource{{Name: "never", URL: "http://127.0.0.1:0"}}, GatewayStaleAfter(time.Nanosecond))
+	statuses := g.Status()
+	assert.True(t, statuses[0].Stale)
+	assert.NotNil(t, g.Refresh(context.Background()))
+	assert.NotEqual(t, "", g.Status()[0].Error)
+
+	w := httptest.NewRecorder()
+	g.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
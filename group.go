@@ -0,0 +1,253 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/zc2638/swag/types"
+)
+
+// Group registers endpoints under a path prefix with shared options, unlike WithGroup and WithTags
+// it is not cleared after AddEndpoint and it is safe for concurrent registration.
+// The options of a nested group are added to the ones of its parent
+type Group struct {
+	api    *API
+	prefix string
+
+	tags        []Tag
+	security    *SecurityRequirement
+	ownSecurity bool
+	parameters  []Parameter
+	responses   map[string]Response
+	deprecated  bool
+	visibility  Visibility
+	middlewares []func(http.Handler) http.Handler
+}
+
+// GroupOption provides configuration options to a Group
+type GroupOption func(g *Group)
+
+// GroupTags adds the tags to the API and to the endpoints of the group
+func GroupTags(tags ...Tag) GroupOption {
+	return func(g *Group) {
+		for _, tag := range tags {
+			exists := false
+			for _, v := range g.tags {
+				if v.Name == tag.Name {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				g.tags = append(g.tags, tag)
+			}
+		}
+	}
+}
+
+// GroupSecurity adds a security requirement to the endpoints of the group that declare none;
+// it may be used multiple times to accept alternative schemes, and it replaces the requirements of the parent group
+func GroupSecurity(scheme string, scopes ...string) GroupOption {
+	return func(g *Group) {
+		if !g.ownSecurity || g.security == nil || g.security.DisableSecurity {
+			g.security = &SecurityRequirement{}
+			g.ownSecurity = true
+		}
+		g.security.Requirements = append(g.security.Requirements, map[string][]string{scheme: scopes})
+	}
+}
+
+// GroupNoSecurity explicitly sets the endpoints of the group that declare no security to have no security requirements
+func GroupNoSecurity() GroupOption {
+	return func(g *Group) {
+		g.security = &SecurityRequirement{DisableSecurity: true}
+		g.ownSecurity = true
+	}
+}
+
+// GroupParameter adds the parameters to the endpoints of the group,
+// an endpoint parameter with the same location and name takes precedence
+func GroupParameter(params ...Parameter) GroupOption {
+	return func(g *Group) {
+		for _, p := range params {
+			replaced := false
+			for i, v := range g.parameters {
+				if v.In == p.In && v.Name == p.Name {
+					g.parameters[i] = p
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				g.parameters = append(g.parameters, p)
+			}
+		}
+	}
+}
+
+// GroupHeader adds a header parameter to the endpoints of the group, e.g. a tenant header
+func GroupHeader(name string, typ types.ParameterType, description string, required bool) GroupOption {
+	return GroupParameter(Parameter{
+		In:          "header",
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Required:    required,
+	})
+}
+
+// GroupResponse adds the response for the code, e.g. "401" or "default", to the endpoints of the group,
+// an endpoint response for the same code takes precedence
+func GroupResponse(code string, response Response) GroupOption {
+	return func(g *Group) {
+		responses := make(map[string]Response, len(g.responses)+1)
+		for k, v := range g.responses {
+			responses[k] = v
+		}
+		responses[code] = response
+		g.responses = responses
+	}
+}
+
+// GroupDeprecated marks the endpoints of the group as deprecated
+func GroupDeprecated() GroupOption {
+	return func(g *Group) {
+		g.deprecated = true
+	}
+}
+
+// GroupVisibility marks the endpoints of the group that declare no visibility with the visibility
+func GroupVisibility(v Visibility) GroupOption {
+	return func(g *Group) {
+		g.visibility = v
+	}
+}
+
+// GroupMiddleware wraps the handlers of the endpoints of the group with the middlewares,
+// the first middleware is the outermost and the middlewares of the parent group wrap the ones of the group;
+// only the handlers that are a http.Handler or a func(http.ResponseWriter, *http.Request) are wrapped
+func GroupMiddleware(middlewares ...func(http.Handler) http.Handler) GroupOption {
+	return func(g *Group) {
+		g.middlewares = append(g.middlewares, middlewares...)
+	}
+}
+
+// Group returns a Group registering endpoints under the path prefix with the options
+func (a *API) Group(prefix string, opts ...GroupOption) *Group {
+	g := &Group{api: a, prefix: path.Join("/", prefix)}
+	return g.with(opts)
+}
+
+// Group returns a nested Group, the prefix is appended to the prefix of g and the options to its options
+func (g *Group) Group(prefix string, opts ...GroupOption) *Group {
+	child := &Group{
+		api:        g.api,
+		prefix:     path.Join(g.prefix, prefix),
+		tags:       append([]Tag(nil), g.tags...),
+		security:   g.security,
+		parameters: append([]Parameter(nil), g.parameters...),
+		responses:  g.responses,
+		deprecated: g.deprecated,
+		visibility: g.visibility,
+	}
+	child.middlewares = append(child.middlewares, g.middlewares...)
+	return child.with(opts)
+}
+
+func (g *Group) with(opts []GroupOption) *Group {
+	for _, opt := range opts {
+		opt(g)
+	}
+	if len(g.tags) > 0 {
+		g.api.mu.Lock()
+		defer g.api.mu.Unlock()
+		defer g.api.modified()
+
+		g.api.addTags(g.tags)
+	}
+	return g
+}
+
+// Prefix returns the path prefix of the group
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// AddEndpoint adds the specified endpoints to the API under the prefix of the group, with its options
+func (g *Group) AddEndpoint(es ...*Endpoint) {
+	for _, e := range es {
+		g.apply(e)
+	}
+
+	g.api.mu.Lock()
+	defer g.api.mu.Unlock()
+	defer g.api.modified()
+
+	g.api.register(es, g.api.duplicates, g.prefix, g.tags)
+}
+
+// apply applies the options of the group, except the prefix and the tags, to the endpoint
+func (g *Group) apply(e *Endpoint) {
+	if e.Security == nil && g.security != nil {
+		e.Security = g.security.clone()
+	}
+
+	if len(g.parameters) > 0 {
+		parameters := make([]Parameter, 0, len(g.parameters)+len(e.Parameters))
+		for _, p := range g.parameters {
+			declared := false
+			for _, v := range e.Parameters {
+				if v.In == p.In && v.Name == p.Name {
+					declared = true
+					break
+				}
+			}
+			if !declared {
+				p.Schema = p.Schema.clone()
+				p.Enum = copyStrings(p.Enum)
+				parameters = append(parameters, p)
+			}
+		}
+		e.Parameters = append(parameters, e.Parameters...)
+	}
+
+	for code, response := range g.responses {
+		if e.Responses == nil {
+			e.Responses = make(map[string]Response)
+		}
+		if _, ok := e.Responses[code]; !ok {
+			e.Responses[code] = response.clone()
+		}
+	}
+
+	if g.deprecated {
+		e.Deprecated = true
+	}
+
+	if e.Visibility == VisibilityPublic {
+		e.Visibility = g.visibility
+	}
+
+	if len(g.middlewares) > 0 && e.Handler != nil {
+		if h, ok := httpHandler(e.Handler); ok {
+			for i := len(g.middlewares) - 1; i >= 0; i-- {
+				h = g.middlewares[i](h)
+			}
+			e.Handler = h
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zc2638/swag/types"
+)
+
+type groupError struct {
+	Message string `json:"message"`
+}
+
+func headerMiddleware(value string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestAPI_Group(t *testing.T) {
+	api := New()
+	v1 := api.Group("/v1",
+		GroupTags(Tag{Name: "v1"}),
+		GroupSecurity("oauth", "read"),
+		GroupHeader("X-Tenant", types.String, "tenant", true),
+		GroupResponse("default", Response{Description: "error", Schema: MakeSchema(groupError{})}),
+		GroupMiddleware(headerMiddleware("v1")),
+	)
+	pets := v1.Group("pets",
+		GroupTags(Tag{Name: "pet"}, Tag{Name: "v1"}),
+		GroupDeprecated(),
+		GroupMiddleware(headerMiddleware("pets")),
+	)
+	assert.Equal(t, "/v1/pets", pets.Prefix())
+
+	pets.AddEndpoint(
+		&Endpoint{
+			Method: http.MethodGet,
+			Path:   "/{id}",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "pet")
+			},
+			Parameters: []Parameter{{In: "header", Name: "X-Tenant", Type: types.Integer}},
+			Responses:  map[string]Response{"default": {Description: "own"}},
+		},
+		&Endpoint{
+			Method:   http.MethodDelete,
+			Path:     "/{id}",
+			Security: &SecurityRequirement{DisableSecurity: true},
+		},
+	)
+	v1.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/stores"})
+	// the group is not cleared by AddEndpoint
+	v1.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/orders"})
+
+	assert.Equal(t, []Tag{{Name: "v1"}, {Name: "pet"}}, api.Tags)
+
+	get := api.Paths["/v1/pets/{id}"].Get
+	assert.Equal(t, []string{"v1", "pet"}, get.Tags)
+	assert.Equal(t, "getV1PetsId", get.OperationID)
+	assert.True(t, get.Deprecated)
+	assert.Equal(t, []Parameter{{In: "header", Name: "X-Tenant", Type: types.Integer}}, get.Parameters)
+	assert.Equal(t, "own", get.Responses["default"].Description)
+	assert.Equal(t, []map[string][]string{{"oauth": {"read"}}}, get.Security.Requirements)
+
+	del := api.Paths["/v1/pets/{id}"].Delete
+	assert.True(t, del.Security.DisableSecurity)
+	assert.Equal(t, "error", del.Responses["default"].Description)
+
+	orders := api.Paths["/v1/orders"].Get
+	assert.Equal(t, []string{"v1"}, orders.Tags)
+	assert.False(t, orders.Deprecated)
+	assert.Len(t, orders.Parameters, 1)
+	assert.Contains(t, api.Definitions, makeName(reflect.TypeOf(groupError{})))
+	orders.Responses["default"].Schema.Ref = "modified"
+	assert.NotEqual(t, "modified", api.Paths["/v1/stores"].Get.Responses["default"].Schema.Ref)
+
+	w := httptest.NewRecorder()
+	api.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/pets/1", nil))
+	assert.Equal(t, "pet", w.Body.String())
+	assert.Equal(t, []string{"v1", "pets"}, w.Header().Values("X-Middleware"))
+}
+
+func TestGroupSecurity(t *testing.T) {
+	api := New()
+	parent := api.Group("/", GroupSecurity("oauth"))
+	child := parent.Group("/internal", GroupSecurity("apikey"), GroupSecurity("basic"))
+	public := parent.Group("/public", GroupNoSecurity())
+
+	e := &Endpoint{Method: http.MethodGet, Path: "/a"}
+	child.AddEndpoint(e)
+	assert.Equal(t, []map[string][]string{{"apikey": nil}, {"basic": nil}}, e.Security.Requirements)
+
+	e = &Endpoint{Method: http.MethodGet, Path: "/b"}
+	parent.AddEndpoint(e)
+	assert.Equal(t, []map[string][]string{{"oauth": nil}}, e.Security.Requirements)
+
+	e = &Endpoint{Method: http.MethodGet, Path: "/c"}
+	public.AddEndpoint(e)
+	assert.True(t, e.Security.DisableSecurity)
+}
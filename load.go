@@ -0,0 +1,196 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadOption provides configuration options to Load
+type LoadOption func(o *loadOptions)
+
+type loadOptions struct {
+	strict bool
+}
+
+// LoadStrict rejects the definitions using keywords that API does not describe, e.g. allOf or the shared
+// parameters and responses, so that nothing is dropped silently; the error lists the json pointers of those keywords
+func LoadStrict() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// Load parses the swagger 2.0 json definition and returns the API described by it.
+//
+// OpenAPI 3 definitions are not supported. The keywords that API does not describe, e.g. allOf or the shared
+// parameters and responses, are dropped unless LoadStrict is set; the vendor extensions, whose names start with x-,
+// are always ignored
+func Load(data []byte, opts ...LoadOption) (*API, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	api := &API{}
+	if err := json.Unmarshal(data, api); err != nil {
+		return nil, fmt.Errorf("parse swagger definition failed: %v", err)
+	}
+	if api.Swagger != string(VersionSwagger2) {
+		return nil, fmt.Errorf("unsupported swagger version %q, only %q is supported", api.Swagger, VersionSwagger2)
+	}
+	if o.strict {
+		if unknown, err := unknownKeywords(data, api); err != nil {
+			return nil, err
+		} else if len(unknown) > 0 {
+			return nil, fmt.Errorf("unsupported keywords in swagger definition: %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	for p, endpoints := range api.Paths {
+		if endpoints == nil {
+			delete(api.Paths, p)
+			continue
+		}
+		endpoints.bind(p)
+	}
+	return api, nil
+}
+
+// LoadReader reads the swagger 2.0 json definition from r and returns the API described by it
+func LoadReader(r io.Reader, opts ...LoadOption) (*API, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, opts...)
+}
+
+// LoadFS reads the swagger 2.0 json definition from the named file of fsys and returns the API described by it
+func LoadFS(fsys fs.FS, name string, opts ...LoadOption) (*API, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, opts...)
+}
+
+// unknownKeywords returns the json pointers of the keywords of the definition which are lost by the API
+func unknownKeywords(data []byte, api *API) ([]string, error) {
+	encoded, err := json.Marshal(api)
+	if err != nil {
+		return nil, err
+	}
+	var in, out interface{}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	var walk func(pointer string, in, out interface{})
+	walk = func(pointer string, in, out interface{}) {
+		switch v := in.(type) {
+		case map[string]interface{}:
+			o, _ := out.(map[string]interface{})
+			for key, value := range v {
+				child := pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+				if ov, ok := o[key]; ok {
+					walk(child, value, ov)
+				} else if !strings.HasPrefix(key, "x-") && !isEmptyJSON(value) {
+					unknown = append(unknown, child)
+				}
+			}
+		case []interface{}:
+			o, _ := out.([]interface{})
+			if len(o) != len(v) {
+				return
+			}
+			for i := range v {
+				walk(pointer+"/"+strconv.Itoa(i), v[i], o[i])
+			}
+		}
+	}
+	walk("", in, out)
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// isEmptyJSON reports whether the decoded json value is null, false, zero or empty,
+// i.e. a value omitted by the encoding of the API
+func isEmptyJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// bind restores the path and method of each endpoint, which are not part of the swagger definition
+func (e *Endpoints) bind(p string) {
+	set := func(endpoint *Endpoint, method string) {
+		if endpoint == nil {
+			return
+		}
+		endpoint.Path = p
+		endpoint.Method = method
+	}
+	set(e.Delete, http.MethodDelete)
+	set(e.Head, http.MethodHead)
+	set(e.Get, http.MethodGet)
+	set(e.Options, http.MethodOptions)
+	set(e.Post, http.MethodPost)
+	set(e.Put, http.MethodPut)
+	set(e.Patch, http.MethodPatch)
+	set(e.Trace, http.MethodTrace)
+	set(e.Connect, http.MethodConnect)
+}
+
+// parameters returns the parameters of the endpoint preceded by the parameters of the path it does not override
+func (e *Endpoints) parameters(endpoint *Endpoint) []Parameter {
+	if e == nil || len(e.Parameters) == 0 {
+		return endpoint.Parameters
+	}
+	overridden := make(map[string]struct{}, len(endpoint.Parameters))
+	for _, p := range endpoint.Parameters {
+		overridden[p.In+" "+p.Name] = struct{}{}
+	}
+	result := make([]Parameter, 0, len(e.Parameters)+len(endpoint.Parameters))
+	for _, p := range e.Parameters {
+		if _, ok := overridden[p.In+" "+p.Name]; !ok {
+			result = append(result, p)
+		}
+	}
+	return append(result, endpoint.Parameters...)
+}
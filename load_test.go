@@ -0,0 +1,214 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zc2638/swag/types"
+)
+
+func TestLoad(t *testing.T) {
+	api := New()
+	api.Host = "example.com"
+	api.SecurityDefinitions = map[string]SecurityScheme{"basic": {Type: "basic"}}
+	api.AddEndpoint(
+		&Endpoint{
+			Method: http.MethodPost,
+			Path:   "/pets",
+			Parameters: []Parameter{
+				{In: "body", Name: "body", Required: true, Schema: MakeSchema(Pet{})},
+				{In: "query", Name: "limit", Type: types.Integer, Default: "10"},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "success",
+					Schema:      MakeSchema([]Pet{}),
+					Headers:     map[string]Header{"X-Rate-Limit": {Type: types.Integer, Format: "int32"}},
+				},
+			},
+		},
+		&Endpoint{
+			Method:   http.MethodPut,
+			Path:     "/pets/{id}/photo",
+			Consumes: []string{"multipart/form-data"},
+			Parameters: []Parameter{
+				{In: "path", Name: "id", Type: types.String},
+				{In: "formData", Name: "file", Type: types.File, Required: true},
+			},
+		},
+	)
+	api.Security = &SecurityRequirement{Requirements: []map[string][]string{{"basic": {}}}}
+	api.Paths["/pets"].Post.Security = &SecurityRequirement{DisableSecurity: true}
+	expected, err := json.Marshal(api)
+	assert.Nil(t, err)
+
+	loaded, err := Load(expected)
+	if !assert.Nil(t, err) {
+		return
+	}
+	actual, err := json.Marshal(loaded)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	post := loaded.Paths["/pets"].Post
+	assert.Equal(t, "/pets", post.Path)
+	assert.Equal(t, http.MethodPost, post.Method)
+	assert.True(t, post.Security.DisableSecurity)
+
+	// adding endpoints to a loaded api must keep the loaded definitions
+	loaded.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Responses: post.Responses})
+	expected, _ = json.Marshal(api.Definitions)
+	actual, _ = json.Marshal(loaded.Definitions)
+	assert.JSONEq(t, string(expected), string(actual))
+}
+
+func TestLoadFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/petstore.json")
+	if !assert.Nil(t, err) {
+		return
+	}
+	api, err := Load(data)
+	if !assert.Nil(t, err) {
+		return
+	}
+	actual, err := json.Marshal(api)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(data), string(actual))
+
+	list := api.Paths["/pets"].Get
+	req := httptest.NewRequest(http.MethodGet, "/pets?status=available&status=lost&limit=0", nil)
+	assert.Equal(t, ValidationErrors{
+		{In: "query", Name: "status", Message: "must be one of [available, sold]"},
+		{In: "query", Name: "limit", Message: "must be at least 1"},
+	}, api.ValidateRequest(list, req))
+
+	// the parameters of the path apply to all its endpoints
+	get := api.Paths["/pets/{id}"].Get
+	req = httptest.NewRequest(http.MethodGet, "/pets/0", nil)
+	req = req.WithContext(types.AddURLParamsToContext(req.Context(), map[string]string{"id": "0"}))
+	assert.Equal(t, ValidationErrors{{In: "path", Name: "id", Message: "must be at least 1"}}, api.ValidateRequest(get, req))
+
+	doc := api.OpenAPI3()
+	item := doc.Paths["/pets/{id}"]
+	if assert.Len(t, item.Get.Parameters, 1) {
+		assert.Equal(t, "id", item.Get.Parameters[0].Name)
+	}
+	assert.Contains(t, item.Get.Responses["200"].Content, "application/json")
+	assert.Equal(t, "id", item.Delete.Parameters[0].Name)
+	assert.Contains(t, doc.Paths["/pets"].Get.Parameters[0].Schema.Items.Enum, "sold")
+	owner := doc.Components.Schemas["Pet"].Properties["owner"]
+	assert.Equal(t, []string{"name"}, owner.Required)
+	assert.Contains(t, owner.Properties, "address")
+
+	// the shared parameters and media types are kept by the mounted endpoints
+	store := New()
+	assert.Nil(t, store.Mount("/store", api))
+	mounted := store.Paths["/store/pets/{id}"].Delete
+	if assert.Len(t, mounted.Parameters, 1) {
+		assert.Equal(t, "id", mounted.Parameters[0].Name)
+	}
+	assert.Equal(t, []string{"application/json"}, mounted.Consumes)
+	assert.Equal(t, []string{"text/plain"}, mounted.Produces)
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	data, err := os.ReadFile("testdata/store.json")
+	if !assert.Nil(t, err) {
+		return
+	}
+	api, err := Load(data, LoadStrict())
+	if !assert.Nil(t, err) {
+		return
+	}
+	actual, err := json.Marshal(api)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(data), string(actual), "the definition has no license")
+
+	list := api.Paths["/orders"].Get
+	assert.ElementsMatch(t, ValidationErrors{
+		{In: "body", Name: "[0].code", Message: "is required"},
+		{In: "body", Name: "[0].status", Message: "must be one of [placed, approved, delivered]"},
+	}, api.ValidateResponse(list, http.StatusOK, http.Header{}, []byte(`[{"status":"lost"}]`)))
+	inventory := api.Paths["/inventory"].Get
+	assert.Len(t, api.ValidateResponse(inventory, http.StatusOK, http.Header{}, []byte(`{"a":1,"b":"2"}`)), 1)
+
+	doc := api.OpenAPI3()
+	assert.Equal(t, "#/components/schemas/Order", doc.Components.Schemas["Orders"].Items.Ref)
+	assert.Equal(t, SchemaType{"integer"}, doc.Components.Schemas["Inventory"].AdditionalProperties.Type)
+	assert.Equal(t, []interface{}{"placed", "approved", "delivered"}, doc.Components.Schemas["Status"].Enum)
+	code := doc.Components.Schemas["Order"].Properties["code"]
+	assert.Equal(t, "^[A-Z]{3}-[0-9]+$", code.Pattern)
+	assert.Equal(t, 16, code.MaxLength)
+
+	// the definitions referenced by the array items are still used
+	assert.True(t, api.RemoveEndpoint(http.MethodGet, "/inventory"))
+	assert.ElementsMatch(t, []string{"Order", "Orders", "Status"}, keys(api.Definitions))
+}
+
+func TestLoadUnknownKeywords(t *testing.T) {
+	data := []byte(`{
+		"swagger": "2.0",
+		"parameters": {"id": {"in": "path", "name": "id", "type": "string"}},
+		"paths": {"/pets/{id}": {"get": {"x-internal": true, "parameters": [{"$ref": "#/parameters/id"}]}}}
+	}`)
+	api, err := Load(data)
+	if assert.Nil(t, err) {
+		assert.NotNil(t, api.Paths["/pets/{id}"].Get, "the unknown keywords are dropped")
+	}
+
+	_, err = Load(data, LoadStrict())
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "/parameters, /paths/~1pets~1{id}/get/parameters/0/$ref")
+		assert.NotContains(t, err.Error(), "x-internal")
+	}
+
+	_, err = Load([]byte(`{"swagger":"2.0","paths":{"/pets":{"get":{"deprecated":false,"x-internal":true}}}}`), LoadStrict())
+	assert.Nil(t, err)
+}
+
+func TestLoadReader(t *testing.T) {
+	_, err := LoadReader(bytes.NewReader([]byte(`{"swagger":"2.0","paths":{"/":{"get":{}}}}`)))
+	assert.Nil(t, err)
+
+	_, err = LoadReader(bytes.NewReader([]byte(`{"openapi":"3.0.3"}`)))
+	assert.NotNil(t, err)
+
+	_, err = LoadReader(bytes.NewReader([]byte(`{`)))
+	assert.NotNil(t, err)
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"swagger.json": &fstest.MapFile{Data: []byte(`{"swagger":"2.0","paths":{"/pets/{id}":{"get":{"operationId":"getPet"}}}}`)},
+	}
+	api, err := LoadFS(fsys, "swagger.json")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "getPet", api.Paths["/pets/{id}"].Get.OperationID)
+	assert.Equal(t, "/pets/{id}", api.Paths["/pets/{id}"].Get.Path)
+
+	_, err = LoadFS(fsys, "missing.json")
+	assert.NotNil(t, err)
+}
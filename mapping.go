@@ -0,0 +1,96 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/zc2638/swag/types"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// stdlibTypes maps the standard library types to the schemas of their json representation;
+	// net.IP is encoded as either an IPv4 or an IPv6 address, no standard format covers both.
+	// url.URL is not mapped, encoding/json encodes its fields
+	stdlibTypes = map[reflect.Type]Property{
+		reflect.TypeOf(time.Time{}):      {Type: types.String.String(), Format: "date-time"},
+		reflect.TypeOf(time.Duration(0)): {Type: types.Integer.String(), Format: "int64"},
+		reflect.TypeOf(net.IP{}):         {Type: types.String.String()},
+		reflect.TypeOf(big.Int{}):        {Type: types.Integer.String()},
+	}
+)
+
+var (
+	customMu    sync.RWMutex
+	customTypes = make(map[reflect.Type]Property)
+)
+
+// RegisterType maps the Go type of the prototype, or the reflect.Type itself, to the schema describing it,
+// e.g. for third-party types whose json representation differs from their fields:
+//
+//	swag.RegisterType(decimal.Decimal{}, swag.Property{Type: "string", Format: "decimal"})
+//	swag.RegisterType(uuid.UUID{}, swag.Property{Type: "string", Format: "uuid"})
+//
+// The mapping applies to the fields, the items, the map values and the schemas of the type, it takes
+// precedence over reflection and over the standard library mappings. A pointer to the type is nullable
+func RegisterType(prototype interface{}, schema Property) {
+	t, ok := prototype.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(prototype)
+	}
+
+	customMu.Lock()
+	defer customMu.Unlock()
+	customTypes[t] = schema.clone()
+}
+
+// knownType returns the schema of the types that are not described by reflection,
+// i.e. the types registered with RegisterType, the standard library types
+// and the byte slices, which are encoded in base64
+func knownType(t reflect.Type) (Property, bool) {
+	customMu.RLock()
+	p, ok := customTypes[t]
+	customMu.RUnlock()
+	if ok {
+		p = p.clone()
+		p.GoType = t
+		return p, true
+	}
+
+	if p, ok := stdlibTypes[t]; ok {
+		p.GoType = t
+		return p, true
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		elem := reflect.PtrTo(t.Elem())
+		if !elem.Implements(jsonMarshalerType) && !elem.Implements(textMarshalerType) {
+			return Property{GoType: t, Type: types.String.String(), Format: "byte"}, true
+		}
+	}
+	return Property{}, false
+}
+
+// isKnownType reports whether the type has a schema in knownType
+func isKnownType(t reflect.Type) bool {
+	_, ok := knownType(t)
+	return ok
+}
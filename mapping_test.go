@@ -0,0 +1,107 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mappingDecimal struct {
+	coefficient int64
+	exponent    int32
+}
+
+type mappingID [16]byte
+
+type mappingNullString struct {
+	String string
+	Valid  bool
+}
+
+type mappingOrder struct {
+	Price    mappingDecimal            `json:"price"`
+	Discount *mappingDecimal           `json:"discount"`
+	ID       mappingID                 `json:"id"`
+	Items    []mappingID               `json:"items"`
+	Prices   map[string]mappingDecimal `json:"prices"`
+	Note     mappingNullString         `json:"note"`
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType(mappingDecimal{}, Property{Type: "string", Format: "decimal", Example: "1.50"})
+	RegisterType(reflect.TypeOf(mappingID{}), Property{Type: "string", Format: "uuid"})
+	RegisterType(mappingNullString{}, Property{Type: "string", Nullable: true})
+
+	v := define(mappingOrder{})
+	assert.Len(t, v, 1, "the registered types are described inline")
+
+	obj := v[makeName(reflect.TypeOf(mappingOrder{}))]
+	assert.Empty(t, checkObject(obj))
+
+	tests := map[string]string{
+		"price":    `{"type":"string","format":"decimal","example":"1.50"}`,
+		"discount": `{"type":"string","format":"decimal","example":"1.50"}`,
+		"id":       `{"type":"string","format":"uuid"}`,
+		"items":    `{"type":"array","items":{"type":"string","format":"uuid"}}`,
+		"prices":   `{"type":"object","additionalProperties":{"type":"string","format":"decimal","example":"1.50"}}`,
+		"note":     `{"type":"string"}`,
+	}
+	for name, expected := range tests {
+		data, err := json.Marshal(obj.Properties[name])
+		assert.Nil(t, err)
+		assert.JSONEq(t, expected, string(data), name)
+	}
+	assert.False(t, obj.Properties["price"].Nullable)
+	assert.True(t, obj.Properties["discount"].Nullable)
+	assert.True(t, obj.Properties["note"].Nullable)
+
+	schema := MakeSchema(mappingID{})
+	assert.Equal(t, "string", schema.Type)
+	assert.Equal(t, "uuid", schema.Format)
+	schema = MakeSchema([]mappingDecimal{})
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "decimal", schema.Items.Format)
+	assert.Empty(t, define([]mappingDecimal{}))
+
+	// the registered schema is copied
+	p, ok := knownType(reflect.TypeOf(mappingDecimal{}))
+	assert.True(t, ok)
+	p.Example = "2"
+	p, _ = knownType(reflect.TypeOf(mappingDecimal{}))
+	assert.Equal(t, "1.50", p.Example)
+}
+
+func TestRegisterType_Ref(t *testing.T) {
+	type money struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	type mappingMoney struct{ cents int64 }
+	RegisterType(mappingMoney{}, Property{Ref: makeRef("Money")})
+
+	api := New()
+	api.Definitions = map[string]Object{"Money": defineObject(money{}, "")}
+	api.AddEndpoint(&Endpoint{
+		Method:    "GET",
+		Path:      "/balance",
+		Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema(mappingMoney{})}},
+	})
+	assert.Equal(t, makeRef("Money"), api.Paths["/balance"].Get.Responses["200"].Schema.Ref)
+	assert.Len(t, api.Definitions, 1)
+}
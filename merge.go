@@ -0,0 +1,193 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeError reports the conflicts found while mounting or merging APIs,
+// for each conflict the element that was already present is kept
+type MergeError struct {
+	Conflicts []string
+}
+
+func (e *MergeError) Error() string {
+	return "merge conflicts, " + strings.Join(e.Conflicts, "; ")
+}
+
+// Mount adds the endpoints of other to the API under the path prefix, together with the definitions,
+// tags and security definitions they need; the base path of other is ignored, the endpoints are
+// relative to the base path of the API. The endpoints of other without security requirement keep
+// the global security of other if it declares one, otherwise they inherit the global security of the API;
+// the same goes for the media types, and the parameters shared by a path are copied to its endpoints.
+// The endpoints are copied, the handlers are shared; the generated operationIds are named again after the full path.
+// Mount returns a *MergeError listing the endpoints, definitions, security definitions and custom operationIds
+// that conflict with the ones of the API
+func (a *API) Mount(prefix string, other *API) error {
+	src := other.Clone()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	if conflicts := a.mount(prefix, src); len(conflicts) > 0 {
+		return &MergeError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// Merge returns a new API combining the apis, the first api provides the info, host, base path,
+// schemes, media types and global security of the result; the other apis are mounted as by Mount without prefix.
+// Merge returns the result and a *MergeError listing all the conflicts
+func Merge(apis ...*API) (*API, error) {
+	if len(apis) == 0 {
+		return New(), nil
+	}
+
+	result := apis[0].Clone()
+	result.clean()
+	var conflicts []string
+	for _, api := range apis[1:] {
+		conflicts = append(conflicts, result.mount("", api.Clone())...)
+	}
+	if len(conflicts) > 0 {
+		return result, &MergeError{Conflicts: conflicts}
+	}
+	return result, nil
+}
+
+// mount moves the endpoints of src, which must not be shared, under the prefix; the caller must hold the write lock
+func (a *API) mount(prefix string, src *API) []string {
+	var conflicts []string
+
+	if src.Security != nil && !equalJSON(src.Security, a.Security) {
+		for _, endpoints := range src.Paths {
+			endpoints.Walk(func(e *Endpoint) {
+				if e.Security == nil {
+					e.Security = src.Security.clone()
+				}
+			})
+		}
+	}
+
+	// the media types and the parameters shared by src or by its paths are not shared under a
+	consumes := src.Consumes != nil && !equalJSON(src.Consumes, a.Consumes)
+	produces := src.Produces != nil && !equalJSON(src.Produces, a.Produces)
+	for _, endpoints := range src.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if consumes && e.Consumes == nil {
+				e.Consumes = copyStrings(src.Consumes)
+			}
+			if produces && e.Produces == nil {
+				e.Produces = copyStrings(src.Produces)
+			}
+			e.Parameters = endpoints.parameters(e)
+		})
+		endpoints.Parameters = nil
+	}
+
+	used := make(map[string]struct{})
+	for _, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if e.OperationID != "" {
+				used[e.OperationID] = struct{}{}
+			}
+		})
+	}
+	for _, p := range src.sortedPaths() {
+		fullPath := path.Join("/", prefix, p)
+		src.Paths[p].Walk(func(e *Endpoint) {
+			method := strings.ToUpper(e.Method)
+			if a.lookup(method, fullPath) != nil {
+				conflicts = append(conflicts, fmt.Sprintf("endpoint %s %s", method, fullPath))
+				return
+			}
+			e.Method = method
+			e.Path = fullPath
+			if e.generatedID {
+				// the operationId was named after the relative path, it is named again after the full path
+				e.OperationID = a.buildOperationID(e)
+			} else if e.OperationID != "" {
+				if _, ok := used[e.OperationID]; ok {
+					conflicts = append(conflicts, fmt.Sprintf("operationId %s of %s %s", e.OperationID, method, fullPath))
+				}
+			}
+			if e.OperationID != "" {
+				used[e.OperationID] = struct{}{}
+			}
+			a.addPath(e)
+		})
+	}
+
+	names := make([]string, 0, len(src.Definitions))
+	for name := range src.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		obj := src.Definitions[name]
+		if current, ok := a.Definitions[name]; ok {
+			if !equalJSON(current, obj) {
+				conflicts = append(conflicts, "definition "+name)
+			}
+			continue
+		}
+		if a.Definitions == nil {
+			a.Definitions = make(map[string]Object)
+		}
+		a.Definitions[name] = obj
+	}
+
+	names = names[:0]
+	for name := range src.SecurityDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := src.SecurityDefinitions[name]
+		if current, ok := a.SecurityDefinitions[name]; ok {
+			if !reflect.DeepEqual(current, scheme) {
+				conflicts = append(conflicts, "security definition "+name)
+			}
+			continue
+		}
+		if a.SecurityDefinitions == nil {
+			a.SecurityDefinitions = make(map[string]SecurityScheme)
+		}
+		a.SecurityDefinitions[name] = scheme
+	}
+
+	a.addTags(src.Tags)
+	return conflicts
+}
+
+// equalJSON reports whether a and b have the same JSON representation
+func equalJSON(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(x) == string(y)
+}
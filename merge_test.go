@@ -0,0 +1,117 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPI_Mount(t *testing.T) {
+	pet := Object{Type: "object", Properties: map[string]Property{"name": {Type: "string"}}}
+	users := New()
+	users.AddTag("users", "")
+	users.AddEndpoint(&Endpoint{
+		Method:      http.MethodGet,
+		Path:        "/users",
+		OperationID: "list",
+		Handler:     http.NotFoundHandler(),
+	})
+	users.Definitions["Pet"] = pet
+	users.SecurityDefinitions = map[string]SecurityScheme{"apikey": {Type: "apiKey", Name: "X-Key", In: "header"}}
+	users.Security = &SecurityRequirement{Requirements: []map[string][]string{{"apikey": {}}}}
+
+	api := New()
+	api.AddTag("main", "")
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets"})
+	api.Definitions["Pet"] = pet
+	assert.Nil(t, api.Mount("/v1", users))
+
+	e := api.Paths["/v1/users"].Get
+	assert.Equal(t, "/v1/users", e.Path)
+	assert.NotNil(t, e.Handler)
+	assert.Equal(t, users.Security, e.Security, "the global security of the mounted api is kept")
+	assert.Contains(t, api.SecurityDefinitions, "apikey")
+	assert.Equal(t, []Tag{{Name: "main"}, {Name: "users"}}, api.Tags)
+	assert.Nil(t, users.Paths["/users"].Get.Security, "the mounted api is not modified")
+	assert.Equal(t, "/users", users.Paths["/users"].Get.Path)
+
+	other := New()
+	other.AddEndpoint(
+		&Endpoint{Method: http.MethodGet, Path: "/users", OperationID: "list"},
+		&Endpoint{Method: http.MethodPost, Path: "/users", OperationID: "getPets"},
+	)
+	other.Definitions["Pet"] = Object{Type: "object", Properties: map[string]Property{"id": {Type: "integer"}}}
+	other.SecurityDefinitions = map[string]SecurityScheme{"apikey": {Type: "apiKey", Name: "X-Other", In: "header"}}
+
+	err := api.Mount("/v1", other)
+	var mergeErr *MergeError
+	assert.True(t, errors.As(err, &mergeErr))
+	assert.Equal(t, []string{
+		"endpoint GET /v1/users",
+		"operationId getPets of POST /v1/users",
+		"definition Pet",
+		"security definition apikey",
+	}, mergeErr.Conflicts)
+	assert.NotNil(t, api.Paths["/v1/users"].Post)
+	assert.Equal(t, pet, api.Definitions["Pet"], "the first definition is kept")
+}
+
+func TestAPI_MountGeneratedOperationID(t *testing.T) {
+	api := New()
+	for _, prefix := range []string{"/users", "/orders"} {
+		module := New()
+		module.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/health"})
+		assert.Equal(t, "getHealth", module.Paths["/health"].Get.OperationID)
+		assert.Nil(t, api.Mount(prefix, module))
+	}
+	assert.Equal(t, "getUsersHealth", api.Paths["/users/health"].Get.OperationID)
+	assert.Equal(t, "getOrdersHealth", api.Paths["/orders/health"].Get.OperationID)
+	assert.Nil(t, api.Validate())
+}
+
+func TestMerge(t *testing.T) {
+	var apis []*API
+	for _, v := range []struct{ title, path string }{
+		{title: "a", path: "/a"},
+		{title: "b", path: "/b"},
+		{title: "c", path: "/a"},
+	} {
+		api := New()
+		api.Info.Title = v.title
+		api.AddTag(v.title, "")
+		api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: v.path})
+		apis = append(apis, api)
+	}
+	a, b, c := apis[0], apis[1], apis[2]
+
+	result, err := Merge(a, b)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", result.Info.Title)
+	assert.Len(t, result.Paths, 2)
+	assert.Len(t, a.Paths, 1)
+
+	result, err = Merge(a, b, c)
+	assert.EqualError(t, err, "merge conflicts, endpoint GET /a")
+	assert.Len(t, result.Paths, 2)
+	assert.Len(t, result.Tags, 3)
+
+	result, err = Merge()
+	assert.Nil(t, err)
+	assert.Equal(t, New(), result)
+}
@@ -0,0 +1,658 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/zc2638/swag/types"
+)
+
+// Version represents the specification version used to render the API
+type Version string
+
+const (
+	// VersionSwagger2 renders the API as a Swagger 2.0 document
+	VersionSwagger2 Version = "2.0"
+	// VersionOpenAPI3 renders the API as an OpenAPI 3.0 document
+	VersionOpenAPI3 Version = "3.0.3"
+	// VersionOpenAPI31 renders the API as an OpenAPI 3.1 document, whose schemas are JSON Schema 2020-12
+	VersionOpenAPI31 Version = "3.1.0"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect used by OpenAPI 3.1 documents and JSONSchema
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// OpenAPI provides the top level encapsulation for the OpenAPI 3 definition
+type OpenAPI struct {
+	OpenAPI           string               `json:"openapi"`
+	Info              Info                 `json:"info"`
+	JSONSchemaDialect string               `json:"jsonSchemaDialect,omitempty"`
+	Servers           []Server             `json:"servers,omitempty"`
+	Paths             map[string]*PathItem `json:"paths"`
+	Components        *Components          `json:"components,omitempty"`
+	Security          *SecurityRequirement `json:"security,omitempty"`
+	Tags              []Tag                `json:"tags,omitempty"`
+}
+
+// Server represents the server entity from the OpenAPI 3 definition
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components represents the components entity from the OpenAPI 3 definition
+type Components struct {
+	Schemas         map[string]*SchemaObject        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecuritySchemeObject `json:"securitySchemes,omitempty"`
+}
+
+// PathItem represents all the operations associated with a particular path in the OpenAPI 3 definition
+type PathItem struct {
+	Delete  *Operation `json:"delete,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Get     *Operation `json:"get,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation represents an operation from the OpenAPI 3 definition
+type Operation struct {
+	Tags        []string                  `json:"tags,omitempty"`
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	OperationID string                    `json:"operationId,omitempty"`
+	Parameters  []ParameterObject         `json:"parameters,omitempty"`
+	RequestBody *RequestBody              `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseObject `json:"responses,omitempty"`
+	Security    *SecurityRequirement      `json:"security,omitempty"`
+	Deprecated  bool                      `json:"deprecated,omitempty"`
+}
+
+// ParameterObject represents a non-body parameter from the OpenAPI 3 definition
+type ParameterObject struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Style       string        `json:"style,omitempty"`
+	Explode     *bool         `json:"explode,omitempty"`
+	Schema      *SchemaObject `json:"schema,omitempty"`
+}
+
+// RequestBody represents a request body from the OpenAPI 3 definition
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// MediaType represents a media type from the OpenAPI 3 definition
+type MediaType struct {
+	Schema *SchemaObject `json:"schema,omitempty"`
+}
+
+// ResponseObject represents a response from the OpenAPI 3 definition
+type ResponseObject struct {
+	Description string                  `json:"description"`
+	Headers     map[string]HeaderObject `json:"headers,omitempty"`
+	Content     map[string]MediaType    `json:"content,omitempty"`
+}
+
+// HeaderObject represents a response header from the OpenAPI 3 definition
+type HeaderObject struct {
+	Description string        `json:"description,omitempty"`
+	Schema      *SchemaObject `json:"schema,omitempty"`
+}
+
+// SchemaObject represents a schema from the OpenAPI 3 definition;
+// since OpenAPI 3.1 it is a JSON Schema 2020-12 schema
+type SchemaObject struct {
+	Schema               string                   `json:"$schema,omitempty"`
+	Ref                  string                   `json:"$ref,omitempty"`
+	Type                 SchemaType               `json:"type,omitempty"`
+	Format               string                   `json:"format,omitempty"`
+	Description          string                   `json:"description,omitempty"`
+	Nullable             bool                     `json:"nullable,omitempty"`
+	Enum                 []interface{}            `json:"enum,omitempty"`
+	Const                interface{}              `json:"const,omitempty"`
+	Default              interface{}              `json:"default,omitempty"`
+	Example              interface{}              `json:"example,omitempty"`
+	Examples             []interface{}            `json:"examples,omitempty"`
+	Items                *SchemaObject            `json:"items,omitempty"`
+	MinItems             int                      `json:"minItems,omitempty"`
+	MaxItems             int                      `json:"maxItems,omitempty"`
+	Minimum              *float64                 `json:"minimum,omitempty"`
+	Maximum              *float64                 `json:"maximum,omitempty"`
+	Pattern              string                   `json:"pattern,omitempty"`
+	MinLength            int                      `json:"minLength,omitempty"`
+	MaxLength            int                      `json:"maxLength,omitempty"`
+	Required             []string                 `json:"required,omitempty"`
+	Properties           map[string]*SchemaObject `json:"properties,omitempty"`
+	AdditionalProperties *SchemaObject            `json:"additionalProperties,omitempty"`
+	AllOf                []*SchemaObject          `json:"allOf,omitempty"`
+	AnyOf                []*SchemaObject          `json:"anyOf,omitempty"`
+	OneOf                []*SchemaObject          `json:"oneOf,omitempty"`
+	Defs                 map[string]*SchemaObject `json:"$defs,omitempty"`
+}
+
+// SchemaType represents the type of schema;
+// JSON Schema 2020-12 allows multiple types, e.g. ["string", "null"] for nullable values
+type SchemaType []string
+
+func schemaType(v string) SchemaType {
+	if v == "" {
+		return nil
+	}
+	return SchemaType{v}
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err == nil {
+		*t = schemaType(v)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// SecuritySchemeObject represents a security scheme from the OpenAPI 3 definition
+type SecuritySchemeObject struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	In          string      `json:"in,omitempty"`
+	Scheme      string      `json:"scheme,omitempty"`
+	Flows       *OAuthFlows `json:"flows,omitempty"`
+}
+
+// OAuthFlows represents the supported oauth2 flows from the OpenAPI 3 definition
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+// OAuthFlow represents a single oauth2 flow from the OpenAPI 3 definition
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// OpenAPI3 renders the API as an OpenAPI 3.0 document
+func (a *API) OpenAPI3() *OpenAPI {
+	return a.snapshot().openAPI(VersionOpenAPI3)
+}
+
+// OpenAPI31 renders the API as an OpenAPI 3.1 document
+func (a *API) OpenAPI31() *OpenAPI {
+	return a.snapshot().openAPI(VersionOpenAPI31)
+}
+
+func (a *API) openAPI(version Version) *OpenAPI {
+	c := newOpenAPIConverter(version)
+	doc := c.convert(a)
+	if version == VersionOpenAPI31 {
+		doc.JSONSchemaDialect = JSONSchemaDialect
+	}
+	return doc
+}
+
+// JSONSchema returns a JSON Schema 2020-12 document holding all the definitions of the API in $defs
+func (a *API) JSONSchema() *SchemaObject {
+	return jsonSchema(a.snapshot().Definitions, nil)
+}
+
+// JSONSchema takes a prototype and returns the JSON Schema 2020-12 document describing it,
+// the definitions referenced by the prototype are placed in $defs
+func JSONSchema(prototype interface{}) *SchemaObject {
+	return jsonSchema(define(prototype), MakeSchema(prototype))
+}
+
+func jsonSchema(definitions map[string]Object, root *Schema) *SchemaObject {
+	c := &openAPIConverter{version: VersionOpenAPI31, refPrefix: "#/$defs/", jsonSchema: true}
+
+	result := &SchemaObject{}
+	if root != nil {
+		result = c.schema(root)
+	}
+	result.Schema = JSONSchemaDialect
+	if len(definitions) > 0 {
+		result.Defs = make(map[string]*SchemaObject, len(definitions))
+		for name, obj := range definitions {
+			result.Defs[name] = c.object(obj)
+		}
+	}
+	return result
+}
+
+// document returns the definition of the API rendered with the specified version,
+// the API must not be modified concurrently, e.g. a snapshot
+func (a *API) document(version Version) interface{} {
+	switch version {
+	case VersionOpenAPI3, VersionOpenAPI31:
+		return a.openAPI(version)
+	}
+	return a
+}
+
+type openAPIConverter struct {
+	version   Version
+	refPrefix string
+	// jsonSchema reports whether schemas follow JSON Schema 2020-12 rather than the OpenAPI 3.0 subset
+	jsonSchema bool
+	// consumes and produces are the default media types of the endpoints
+	consumes []string
+	produces []string
+}
+
+func newOpenAPIConverter(version Version) *openAPIConverter {
+	return &openAPIConverter{
+		version:    version,
+		refPrefix:  "#/components/schemas/",
+		jsonSchema: version == VersionOpenAPI31,
+	}
+}
+
+func (c *openAPIConverter) convert(a *API) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:  string(c.version),
+		Info:     a.Info,
+		Servers:  c.servers(a),
+		Paths:    make(map[string]*PathItem, len(a.Paths)),
+		Security: a.Security,
+		Tags:     a.Tags,
+	}
+	c.consumes, c.produces = a.Consumes, a.Produces
+	for p, endpoints := range a.Paths {
+		doc.Paths[p] = c.pathItem(endpoints)
+	}
+
+	components := &Components{}
+	if len(a.Definitions) > 0 {
+		components.Schemas = make(map[string]*SchemaObject, len(a.Definitions))
+		for name, obj := range a.Definitions {
+			components.Schemas[name] = c.object(obj)
+		}
+	}
+	if len(a.SecurityDefinitions) > 0 {
+		components.SecuritySchemes = make(map[string]SecuritySchemeObject, len(a.SecurityDefinitions))
+		for name, scheme := range a.SecurityDefinitions {
+			components.SecuritySchemes[name] = c.securityScheme(scheme)
+		}
+	}
+	if components.Schemas != nil || components.SecuritySchemes != nil {
+		doc.Components = components
+	}
+	return doc
+}
+
+func (c *openAPIConverter) servers(a *API) []Server {
+	basePath := strings.TrimSuffix(a.BasePath, "/")
+	if a.Host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []Server{{URL: basePath}}
+	}
+
+	schemes := a.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	servers := make([]Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, Server{URL: scheme + "://" + a.Host + basePath})
+	}
+	return servers
+}
+
+func (c *openAPIConverter) pathItem(e *Endpoints) *PathItem {
+	return &PathItem{
+		Delete:  c.operation(e, e.Delete),
+		Head:    c.operation(e, e.Head),
+		Get:     c.operation(e, e.Get),
+		Options: c.operation(e, e.Options),
+		Post:    c.operation(e, e.Post),
+		Put:     c.operation(e, e.Put),
+		Patch:   c.operation(e, e.Patch),
+		Trace:   c.operation(e, e.Trace),
+	}
+}
+
+func (c *openAPIConverter) operation(endpoints *Endpoints, e *Endpoint) *Operation {
+	if e == nil {
+		return nil
+	}
+	consumes, produces := e.Consumes, e.Produces
+	if consumes == nil {
+		consumes = c.consumes
+	}
+	if produces == nil {
+		produces = c.produces
+	}
+
+	op := &Operation{
+		Tags:        e.Tags,
+		Summary:     e.Summary,
+		Description: e.Description,
+		OperationID: e.OperationID,
+		Security:    e.Security,
+		Deprecated:  e.Deprecated,
+	}
+
+	form := &SchemaObject{Type: schemaType("object")}
+	for _, p := range endpoints.parameters(e) {
+		switch p.In {
+		case "body":
+			op.RequestBody = &RequestBody{
+				Description: p.Description,
+				Required:    p.Required,
+				Content:     c.content(consumes, nil, c.schema(p.Schema)),
+			}
+		case "formData":
+			if form.Properties == nil {
+				form.Properties = make(map[string]*SchemaObject)
+			}
+			form.Properties[p.Name] = c.parameterSchema(p)
+			if p.Required {
+				form.Required = append(form.Required, p.Name)
+			}
+		default:
+			param := ParameterObject{
+				Name:        p.Name,
+				In:          p.In,
+				Description: p.Description,
+				Required:    p.Required || p.In == "path",
+				Schema:      c.parameterSchema(p),
+			}
+			if p.In == "query" && p.Type == types.Array {
+				param.Style, param.Explode = collectionStyle(p.CollectionFormat)
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+	if form.Properties != nil && op.RequestBody == nil {
+		op.RequestBody = &RequestBody{
+			Content: c.content(consumes, isFormMediaType, form),
+		}
+	}
+
+	if len(e.Responses) > 0 {
+		op.Responses = make(map[string]ResponseObject, len(e.Responses))
+		for code, resp := range e.Responses {
+			op.Responses[code] = c.response(produces, resp)
+		}
+	}
+	return op
+}
+
+func (c *openAPIConverter) response(produces []string, resp Response) ResponseObject {
+	r := ResponseObject{Description: resp.Description}
+	if resp.Schema != nil {
+		r.Content = c.content(produces, nil, c.schema(resp.Schema))
+	}
+	if len(resp.Headers) > 0 {
+		r.Headers = make(map[string]HeaderObject, len(resp.Headers))
+		for name, h := range resp.Headers {
+			r.Headers[name] = HeaderObject{
+				Description: h.Description,
+				Schema:      &SchemaObject{Type: schemaType(h.Type.String()), Format: h.Format},
+			}
+		}
+	}
+	return r
+}
+
+// content builds the content map of the media types accepted by filter,
+// falling back to the first default media type if none is accepted
+func (c *openAPIConverter) content(mediaTypes []string, filter func(string) bool, schema *SchemaObject) map[string]MediaType {
+	result := make(map[string]MediaType)
+	for _, v := range mediaTypes {
+		if filter != nil && !filter(v) {
+			continue
+		}
+		result[v] = MediaType{Schema: schema}
+	}
+	if len(result) == 0 {
+		mediaType := "application/json"
+		if filter != nil {
+			mediaType = "multipart/form-data"
+		}
+		result[mediaType] = MediaType{Schema: schema}
+	}
+	return result
+}
+
+func isFormMediaType(v string) bool {
+	return v == "multipart/form-data" || v == "application/x-www-form-urlencoded"
+}
+
+func (c *openAPIConverter) parameterSchema(p Parameter) *SchemaObject {
+	if p.Type == types.File {
+		return &SchemaObject{Type: schemaType(types.String.String()), Format: "binary"}
+	}
+	s := &SchemaObject{
+		Type:    schemaType(p.Type.String()),
+		Format:  p.Format,
+		Default: typedValue(p.Type.String(), p.Default),
+		Minimum: p.Minimum,
+		Maximum: p.Maximum,
+		Items:   c.items(p.Items),
+	}
+	for _, v := range p.Enum {
+		s.Enum = append(s.Enum, typedValue(p.Type.String(), v))
+	}
+	return s
+}
+
+// collectionStyle returns the style and explode of a query parameter serialized with the swagger 2.0 collection
+// format; tsv has no equivalent and is left to the defaults
+func collectionStyle(format string) (string, *bool) {
+	explode := false
+	switch format {
+	case "", "csv":
+		return "form", &explode
+	case "ssv":
+		return "spaceDelimited", &explode
+	case "pipes":
+		return "pipeDelimited", &explode
+	}
+	return "", nil
+}
+
+func (c *openAPIConverter) schema(s *Schema) *SchemaObject {
+	if s == nil {
+		return nil
+	}
+	result := &SchemaObject{
+		Ref:    c.ref(s.Ref),
+		Type:   schemaType(s.Type),
+		Format: s.Format,
+		Items:  c.items(s.Items),
+	}
+	if s.AdditionalProperties != nil {
+		result.AdditionalProperties = c.property(*s.AdditionalProperties)
+	}
+	return result
+}
+
+func (c *openAPIConverter) object(obj Object) *SchemaObject {
+	return c.property(obj.property())
+}
+
+func (c *openAPIConverter) property(p Property) *SchemaObject {
+	if p.FreeForm {
+		// the empty schema accepts any value
+		s := &SchemaObject{Description: p.Description}
+		for _, v := range p.OneOf {
+			s.OneOf = append(s.OneOf, c.property(v))
+		}
+		return s
+	}
+	s := &SchemaObject{
+		Ref:       c.ref(p.Ref),
+		Type:      schemaType(p.Type),
+		Format:    p.Format,
+		Default:   p.Default,
+		Items:     c.items(p.Items),
+		MinItems:  p.MinItems,
+		MaxItems:  p.MaxItems,
+		Minimum:   p.Minimum,
+		Maximum:   p.Maximum,
+		Pattern:   p.Pattern,
+		MinLength: p.MinLength,
+		MaxLength: p.MaxLength,
+		Required:  p.Required,
+	}
+	if len(p.Properties) > 0 {
+		s.Properties = make(map[string]*SchemaObject, len(p.Properties))
+		for name, v := range p.Properties {
+			s.Properties[name] = c.property(v)
+		}
+	}
+	if p.AdditionalProperties != nil {
+		s.AdditionalProperties = c.property(*p.AdditionalProperties)
+	}
+	for _, v := range p.Enum {
+		s.Enum = append(s.Enum, typedValue(p.Type, v))
+	}
+	example := typedValue(p.Type, p.Example)
+	if c.jsonSchema {
+		if len(s.Enum) == 1 {
+			s.Const = s.Enum[0]
+			s.Enum = nil
+		}
+		if example != nil {
+			s.Examples = []interface{}{example}
+		}
+	} else {
+		s.Example = example
+	}
+	if p.Nullable {
+		s = c.nullable(s)
+	}
+	s.Description = p.Description
+	return s
+}
+
+// nullable marks the schema as accepting null values
+func (c *openAPIConverter) nullable(s *SchemaObject) *SchemaObject {
+	if s.Ref != "" {
+		// $ref siblings are ignored by OpenAPI 3.0, so the reference has to be wrapped
+		if c.jsonSchema {
+			return &SchemaObject{AnyOf: []*SchemaObject{s, {Type: schemaType("null")}}}
+		}
+		return &SchemaObject{AllOf: []*SchemaObject{s}, Nullable: true}
+	}
+	if c.jsonSchema {
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, "null")
+		}
+	} else {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (c *openAPIConverter) items(items *Items) *SchemaObject {
+	if items == nil {
+		return nil
+	}
+	return c.property(items.property())
+}
+
+func (c *openAPIConverter) ref(ref string) string {
+	return strings.Replace(ref, "#/definitions/", c.refPrefix, 1)
+}
+
+func (c *openAPIConverter) securityScheme(s SecurityScheme) SecuritySchemeObject {
+	result := SecuritySchemeObject{
+		Type:        s.Type,
+		Description: s.Description,
+		Name:        s.Name,
+		In:          s.In,
+	}
+	switch s.Type {
+	case "basic":
+		result.Type = "http"
+		result.Scheme = "basic"
+	case "oauth2":
+		flow := &OAuthFlow{
+			AuthorizationURL: s.AuthorizationURL,
+			TokenURL:         s.TokenURL,
+			Scopes:           s.Scopes,
+		}
+		if flow.Scopes == nil {
+			flow.Scopes = map[string]string{}
+		}
+		result.Flows = &OAuthFlows{}
+		switch s.Flow {
+		case "implicit":
+			flow.TokenURL = ""
+			result.Flows.Implicit = flow
+		case "password":
+			flow.AuthorizationURL = ""
+			result.Flows.Password = flow
+		case "application":
+			flow.AuthorizationURL = ""
+			result.Flows.ClientCredentials = flow
+		default:
+			result.Flows.AuthorizationCode = flow
+		}
+	}
+	return result
+}
+
+// typedValue converts the string representation of a value to the json type described by typ
+func typedValue(typ, v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	switch typ {
+	case types.Integer.String():
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case types.Number.String():
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case types.Boolean.String():
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
@@ -0,0 +1,193 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zc2638/swag/types"
+)
+
+func TestAPI_OpenAPI3(t *testing.T) {
+	api := New()
+	api.Host = "example.com"
+	api.SecurityDefinitions = map[string]SecurityScheme{
+		"basic": {Type: "basic"},
+		"oauth": {Type: "oauth2", Flow: "accessCode", AuthorizationURL: "https://example.com/auth", TokenURL: "https://example.com/token"},
+	}
+	api.AddEndpoint(
+		&Endpoint{
+			Method:   http.MethodPost,
+			Path:     "/pets",
+			Consumes: []string{"application/json"},
+			Produces: []string{"application/json"},
+			Parameters: []Parameter{
+				{In: "body", Name: "body", Required: true, Schema: MakeSchema(Pet{})},
+				{In: "query", Name: "limit", Type: types.Integer, Default: "10"},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "success",
+					Schema:      MakeSchema([]Pet{}),
+					Headers:     map[string]Header{"X-Rate-Limit": {Type: types.Integer, Format: "int32"}},
+				},
+			},
+		},
+		&Endpoint{
+			Method:   http.MethodPut,
+			Path:     "/pets/{id}/photo",
+			Consumes: []string{"multipart/form-data"},
+			Parameters: []Parameter{
+				{In: "path", Name: "id", Type: types.String},
+				{In: "formData", Name: "file", Type: types.File, Required: true},
+				{In: "formData", Name: "name", Type: types.String},
+			},
+		},
+	)
+	doc := api.OpenAPI3()
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, []Server{{URL: "http://example.com"}}, doc.Servers)
+
+	post := doc.Paths["/pets"].Post
+	if assert.NotNil(t, post) && assert.NotNil(t, post.RequestBody) {
+		assert.True(t, post.RequestBody.Required)
+		assert.Equal(t, "#/components/schemas/github.com_zc2638_swag.Pet", post.RequestBody.Content["application/json"].Schema.Ref)
+	}
+	assert.Equal(t, []ParameterObject{{
+		Name:   "limit",
+		In:     "query",
+		Schema: &SchemaObject{Type: SchemaType{"integer"}, Default: int64(10)},
+	}}, post.Parameters)
+
+	resp := post.Responses["200"]
+	schema := resp.Content["application/json"].Schema
+	assert.Equal(t, SchemaType{"array"}, schema.Type)
+	assert.Equal(t, "#/components/schemas/github.com_zc2638_swag.Pet", schema.Items.Ref)
+	assert.Equal(t, SchemaType{"integer"}, resp.Headers["X-Rate-Limit"].Schema.Type)
+
+	put := doc.Paths["/pets/{id}/photo"].Put
+	if assert.NotNil(t, put) && assert.NotNil(t, put.RequestBody) {
+		form := put.RequestBody.Content["multipart/form-data"].Schema
+		assert.Equal(t, SchemaType{"object"}, form.Type)
+		assert.Equal(t, []string{"file"}, form.Required)
+		assert.Equal(t, &SchemaObject{Type: SchemaType{"string"}, Format: "binary"}, form.Properties["file"])
+	}
+	assert.True(t, put.Parameters[0].Required)
+
+	pet := doc.Components.Schemas["github.com_zc2638_swag.Pet"]
+	assert.Equal(t, "#/components/schemas/github.com_zc2638_swag.Person", pet.Properties["friend"].Ref)
+	assert.Equal(t, "#/components/schemas/github.com_zc2638_swag.Person", pet.Properties["friends"].Items.Ref)
+
+	relatives := newOpenAPIConverter(VersionOpenAPI3).schema(MakeSchema(map[string]Person{}))
+	assert.Equal(t, SchemaType{"object"}, relatives.Type)
+	assert.Equal(t, "#/components/schemas/github.com_zc2638_swag.Person", relatives.AdditionalProperties.Ref)
+
+	assert.Equal(t, SecuritySchemeObject{Type: "http", Scheme: "basic"}, doc.Components.SecuritySchemes["basic"])
+	oauth := doc.Components.SecuritySchemes["oauth"]
+	if assert.NotNil(t, oauth.Flows) && assert.NotNil(t, oauth.Flows.AuthorizationCode) {
+		assert.Equal(t, "https://example.com/token", oauth.Flows.AuthorizationCode.TokenURL)
+	}
+}
+
+func TestAPI_HandlerOpenAPI3(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	New().Handler(HandlerVersion(VersionOpenAPI3)).ServeHTTP(w, r)
+
+	var doc OpenAPI
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, []Server{{URL: "https://example.com"}}, doc.Servers)
+}
+
+func TestAPI_OpenAPI31(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{
+		Method:    http.MethodGet,
+		Path:      "/pets",
+		Responses: map[string]Response{"200": {Description: "success", Schema: MakeSchema(Pet{})}},
+	})
+	doc := api.OpenAPI31()
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, JSONSchemaDialect, doc.JSONSchemaDialect)
+
+	pet := doc.Components.Schemas["github.com_zc2638_swag.Pet"]
+	assert.Equal(t, []*SchemaObject{
+		{Ref: "#/components/schemas/github.com_zc2638_swag.Person"},
+		{Type: SchemaType{"null"}},
+	}, pet.Properties["pointer"].AnyOf)
+	assert.Equal(t, []interface{}{"b"}, pet.Properties["enum"].Examples)
+	assert.Nil(t, pet.Properties["enum"].Example)
+
+	data, err := json.Marshal(pet.Properties["pointer"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"anyOf":[{"$ref":"#/components/schemas/github.com_zc2638_swag.Person"},{"type":"null"}]}`, string(data))
+
+	doc = api.OpenAPI3()
+	pet = doc.Components.Schemas["github.com_zc2638_swag.Pet"]
+	assert.True(t, pet.Properties["pointer"].Nullable)
+	assert.Equal(t, "b", pet.Properties["enum"].Example)
+}
+
+func TestJSONSchema(t *testing.T) {
+	type Nullable struct {
+		Name  *string         `json:"name"`
+		Kind  string          `json:"kind" enum:"pet"`
+		Count int             `json:"count" example:"3"`
+		Data  interface{}     `json:"data"`
+		Event json.RawMessage `json:"event" oneof:"jsonSchemaEvent"`
+		Grid  [][2]int        `json:"grid"`
+	}
+	RegisterOneOf("jsonSchemaEvent", Person{}, "")
+
+	s := JSONSchema(Nullable{})
+	assert.Equal(t, JSONSchemaDialect, s.Schema)
+	name := makeName(reflect.TypeOf(Nullable{}))
+	assert.Equal(t, "#/$defs/"+name, s.Ref)
+
+	def := s.Defs[name]
+	if assert.NotNil(t, def) {
+		assert.Equal(t, SchemaType{"string", "null"}, def.Properties["name"].Type)
+		assert.Equal(t, "pet", def.Properties["kind"].Const)
+		assert.Equal(t, []interface{}{int64(3)}, def.Properties["count"].Examples)
+	}
+
+	data, err := json.Marshal(def.Properties["name"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type":["string","null"]}`, string(data))
+
+	data, err = json.Marshal(def.Properties["data"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+
+	data, err = json.Marshal(def.Properties["event"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"oneOf":[{"$ref":"#/$defs/github.com_zc2638_swag.Person"},{"type":"string"}]}`, string(data))
+	assert.Contains(t, s.Defs, "github.com_zc2638_swag.Person")
+
+	data, err = json.Marshal(def.Properties["grid"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type":"array","items":{"type":"array","items":{"type":"integer","format":"int32"},"minItems":2,"maxItems":2}}`, string(data))
+}
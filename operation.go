@@ -0,0 +1,148 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var reClosureName = regexp.MustCompile(`^func\d+$`)
+
+// OperationIDStrategy names the operation of an endpoint registered without operationId
+type OperationIDStrategy func(e *Endpoint) string
+
+// MethodPathOperationID names the operation by the lower case method followed by the camel case path,
+// e.g. getPetsId for GET /pets/{id}; this is the default strategy
+func MethodPathOperationID(e *Endpoint) string {
+	return strings.ToLower(e.Method) + camel(e.Path)
+}
+
+// MethodPathParamOperationID names the operation like MethodPathOperationID but prefixes the path parameters with By,
+// e.g. getPetsById for GET /pets/{id} and getPetsId for GET /pets/id, so that both are named the same whatever
+// the order in which they are registered
+func MethodPathParamOperationID(e *Endpoint) string {
+	segments := strings.Split(e.Path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "By/" + strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		}
+	}
+	return strings.ToLower(e.Method) + camel(strings.Join(segments, "/"))
+}
+
+// HandlerOperationID names the operation by the function name of its handler, e.g. getPet for func GetPet
+// or for the method value (*Server).GetPet; it falls back to MethodPathOperationID for the handlers
+// that are not named functions
+func HandlerOperationID(e *Endpoint) string {
+	if e.Handler != nil {
+		v := reflect.ValueOf(e.Handler)
+		if v.Kind() == reflect.Func && !v.IsNil() {
+			if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+				name := fn.Name()
+				name = name[strings.LastIndex(name, "/")+1:]
+				name = strings.TrimSuffix(name, "-fm")
+				name = name[strings.LastIndex(name, ".")+1:]
+				if name != "" && !reClosureName.MatchString(name) {
+					return strings.ToLower(name[:1]) + name[1:]
+				}
+			}
+		}
+	}
+	return MethodPathOperationID(e)
+}
+
+// TagPrefixedOperationID prefixes the operationIds named by the strategy with the first tag of the endpoint,
+// e.g. petGetPets for GET /pets tagged with pet
+func TagPrefixedOperationID(strategy OperationIDStrategy) OperationIDStrategy {
+	return func(e *Endpoint) string {
+		id := strategy(e)
+		if len(e.Tags) == 0 {
+			return id
+		}
+		prefix := camel(e.Tags[0])
+		if prefix == "" {
+			return id
+		}
+		prefix = strings.ToLower(prefix[:1]) + prefix[1:]
+		if id == "" {
+			return prefix
+		}
+		return prefix + strings.ToUpper(id[:1]) + id[1:]
+	}
+}
+
+// SetOperationIDStrategy sets the strategy naming the operations of the endpoints added without operationId,
+// a nil strategy restores MethodPathOperationID
+func (a *API) SetOperationIDStrategy(strategy OperationIDStrategy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.operationIDs = strategy
+}
+
+// ErrDuplicateOperationID is wrapped by the error raised when an endpoint is added with the operationId
+// of another endpoint
+var ErrDuplicateOperationID = errors.New("duplicate operationId")
+
+// buildOperationID names the operation of the endpoint with the strategy of the API;
+// the name is made unique by appending the smallest number, starting at 2, not used by another endpoint
+func (a *API) buildOperationID(e *Endpoint) string {
+	strategy := a.operationIDs
+	if strategy == nil {
+		strategy = MethodPathOperationID
+	}
+	id := strategy(e)
+
+	used := a.usedOperationIDs(e)
+	if _, ok := used[id]; !ok {
+		return id
+	}
+	for i := 2; ; i++ {
+		candidate := id + strconv.Itoa(i)
+		if _, ok := used[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// checkOperationID returns an error if the operationId of the endpoint is used by another endpoint
+func (a *API) checkOperationID(e *Endpoint) error {
+	if other, ok := a.usedOperationIDs(e)[e.OperationID]; ok {
+		return fmt.Errorf("%w %s of %s %s, used by %s", ErrDuplicateOperationID,
+			e.OperationID, strings.ToUpper(e.Method), e.Path, other)
+	}
+	return nil
+}
+
+// usedOperationIDs returns the operationIds of the registered endpoints and their method and path;
+// the endpoint registered with the same method and path as e is replaced, its operationId is released
+func (a *API) usedOperationIDs(e *Endpoint) map[string]string {
+	current := a.lookup(e.Method, e.Path)
+	used := make(map[string]string)
+	for p, endpoints := range a.Paths {
+		endpoints.Walk(func(endpoint *Endpoint) {
+			if endpoint != current && endpoint != e {
+				used[endpoint.OperationID] = strings.ToUpper(endpoint.Method) + " " + p
+			}
+		})
+	}
+	return used
+}
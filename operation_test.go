@@ -0,0 +1,151 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type operationServer struct{}
+
+func (operationServer) ListPets(http.ResponseWriter, *http.Request) {}
+
+func GetPet(http.ResponseWriter, *http.Request) {}
+
+func TestAPI_OperationID(t *testing.T) {
+	api := New()
+	api.AddEndpoint(
+		&Endpoint{Method: http.MethodGet, Path: "/pets/{id}"},
+		&Endpoint{Method: http.MethodGet, Path: "/pets/id"},
+		&Endpoint{Method: http.MethodGet, Path: "/Pets/Id"},
+		&Endpoint{Method: http.MethodPost, Path: "/pets", OperationID: "createPet"},
+	)
+	assert.Equal(t, "getPetsId", api.Paths["/pets/{id}"].Get.OperationID)
+	assert.Equal(t, "getPetsId2", api.Paths["/pets/id"].Get.OperationID)
+	assert.Equal(t, "getPetsId3", api.Paths["/Pets/Id"].Get.OperationID)
+	assert.Equal(t, "createPet", api.Paths["/pets"].Post.OperationID)
+
+	// the replaced endpoint releases its operationId
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets/id"})
+	assert.Equal(t, "getPetsId2", api.Paths["/pets/id"].Get.OperationID)
+	assert.Nil(t, api.Validate())
+
+	// a custom operationId used by another endpoint is rejected, unless the endpoint replaces it
+	api.AddEndpoint(&Endpoint{Method: http.MethodPost, Path: "/pets", OperationID: "createPet"})
+	assert.Panics(t, func() {
+		api.AddEndpoint(&Endpoint{Method: http.MethodPut, Path: "/pets", OperationID: "createPet"})
+	})
+	api.SetValidating(true)
+	api.AddEndpoint(&Endpoint{Method: http.MethodPut, Path: "/pets", OperationID: "getPetsId"})
+	assert.Nil(t, api.Paths["/pets"].Put)
+	err := api.Err()
+	assert.True(t, errors.Is(err.(*BuildError).Errors[0], ErrDuplicateOperationID))
+	assert.EqualError(t, err, "build failed, duplicate operationId getPetsId of PUT /pets, used by GET /pets/{id}")
+}
+
+func TestAPI_OperationIDPathParam(t *testing.T) {
+	endpoints := func() []*Endpoint {
+		return []*Endpoint{
+			{Method: http.MethodGet, Path: "/pets/{id}"},
+			{Method: http.MethodGet, Path: "/pets/id"},
+			{Method: http.MethodGet, Path: "/pets/{id}/photos/{photoId}"},
+		}
+	}
+	for name, reversed := range map[string]bool{"in order": false, "reversed": true} {
+		t.Run(name, func(t *testing.T) {
+			es := endpoints()
+			if reversed {
+				es[0], es[2] = es[2], es[0]
+			}
+			api := New()
+			api.SetOperationIDStrategy(MethodPathParamOperationID)
+			api.AddEndpoint(es...)
+			assert.Equal(t, "getPetsById", api.Paths["/pets/{id}"].Get.OperationID)
+			assert.Equal(t, "getPetsId", api.Paths["/pets/id"].Get.OperationID)
+			assert.Equal(t, "getPetsByIdPhotosByPhotoId", api.Paths["/pets/{id}/photos/{photoId}"].Get.OperationID)
+		})
+	}
+}
+
+func TestOperationIDStrategy(t *testing.T) {
+	closure := func(http.ResponseWriter, *http.Request) {}
+	tests := []struct {
+		name     string
+		strategy OperationIDStrategy
+		e        *Endpoint
+		want     string
+	}{
+		{
+			name:     "method path",
+			strategy: MethodPathOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets/{id}"},
+			want:     "getPetsId",
+		},
+		{
+			name:     "method path param",
+			strategy: MethodPathParamOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets/{id}"},
+			want:     "getPetsById",
+		},
+		{
+			name:     "handler function",
+			strategy: HandlerOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets/{id}", Handler: http.HandlerFunc(GetPet)},
+			want:     "getPet",
+		},
+		{
+			name:     "handler method",
+			strategy: HandlerOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets", Handler: operationServer{}.ListPets},
+			want:     "listPets",
+		},
+		{
+			name:     "handler closure",
+			strategy: HandlerOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets", Handler: closure},
+			want:     "getPets",
+		},
+		{
+			name:     "handler not a function",
+			strategy: HandlerOperationID,
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets", Handler: http.RedirectHandler("/", http.StatusFound)},
+			want:     "getPets",
+		},
+		{
+			name:     "tag prefixed",
+			strategy: TagPrefixedOperationID(MethodPathOperationID),
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets", Tags: []string{"pet-store"}},
+			want:     "petstoreGetPets",
+		},
+		{
+			name:     "tag prefixed without tag",
+			strategy: TagPrefixedOperationID(MethodPathOperationID),
+			e:        &Endpoint{Method: http.MethodGet, Path: "/pets"},
+			want:     "getPets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := New()
+			api.SetOperationIDStrategy(tt.strategy)
+			api.AddEndpoint(tt.e)
+			assert.Equal(t, tt.want, tt.e.OperationID)
+		})
+	}
+}
@@ -108,3 +108,25 @@ func Security(scheme string, scopes ...string) swag.Option {
 		api.Security.Requirements = append(api.Security.Requirements, map[string][]string{scheme: scopes})
 	}
 }
+
+// Duplicates sets the policy applied to the endpoints registered twice with the same method and path
+func Duplicates(policy swag.DuplicatePolicy) swag.Option {
+	return func(api *swag.API) {
+		api.SetDuplicatePolicy(policy)
+	}
+}
+
+// Validating enables the validating build mode, the errors are recorded instead of panicking,
+// see API.SetValidating
+func Validating() swag.Option {
+	return func(api *swag.API) {
+		api.SetValidating(true)
+	}
+}
+
+// OperationIDStrategy sets the strategy naming the operations of the endpoints added without operationId
+func OperationIDStrategy(strategy swag.OperationIDStrategy) swag.Option {
+	return func(api *swag.API) {
+		api.SetOperationIDStrategy(strategy)
+	}
+}
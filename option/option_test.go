@@ -93,3 +93,30 @@ func TestSecurity(t *testing.T) {
 	assert.Len(t, api.Security.Requirements, 1)
 	assert.Contains(t, api.Security.Requirements[0], "basic")
 }
+
+func TestDuplicates(t *testing.T) {
+	api := swag.New(
+		Duplicates(swag.DuplicateError),
+	)
+	api.AddEndpoint(&swag.Endpoint{Method: "GET", Path: "/pets"})
+	assert.Panics(t, func() {
+		api.AddEndpoint(&swag.Endpoint{Method: "GET", Path: "/pets"})
+	})
+}
+
+func TestValidating(t *testing.T) {
+	api := swag.New(
+		Validating(),
+	)
+	api.AddEndpoint(&swag.Endpoint{Method: "INVALID", Path: "/pets"})
+	assert.EqualError(t, api.Err(), "build failed, invalid method, INVALID")
+}
+
+func TestOperationIDStrategy(t *testing.T) {
+	api := swag.New(
+		OperationIDStrategy(swag.TagPrefixedOperationID(swag.MethodPathOperationID)),
+	)
+	e := &swag.Endpoint{Method: "GET", Path: "/pets", Tags: []string{"pet"}}
+	api.AddEndpoint(e)
+	assert.Equal(t, "petGetPets", e.OperationID)
+}
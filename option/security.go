@@ -31,6 +31,10 @@ func SecurityScheme(name string, options ...SecuritySchemeOption) swag.Option {
 		for _, opt := range options {
 			opt(&scheme)
 		}
+		if scheme.Type == "apiKey" && scheme.In != "header" && scheme.In != "query" {
+			api.AddError(fmt.Errorf(`APIKeySecurity "in" parameter must be one of: "header" or "query"`))
+			return
+		}
 		api.SecurityDefinitions[name] = scheme
 	}
 }
@@ -54,11 +58,9 @@ func BasicSecurity() SecuritySchemeOption {
 
 // APIKeySecurity defines a security scheme for API key authentication. "in" is
 // the location of the API key (query or header). "name" is the name of the
-// header or query parameter to be used.
+// header or query parameter to be used. An invalid "in" is reported by SecurityScheme
+// through API.AddError, i.e. it panics unless the API is in the validating build mode.
 func APIKeySecurity(name, in string) SecuritySchemeOption {
-	if in != "header" && in != "query" {
-		panic(fmt.Errorf(`APIKeySecurity "in" parameter must be one of: "header" or "query"`))
-	}
 	return func(scheme *swag.SecurityScheme) {
 		scheme.Type = "apiKey"
 		scheme.Name = name
@@ -56,9 +56,13 @@ func TestAPIKeySecurity(t *testing.T) {
 	assert.Equal(t, scheme.In, in)
 
 	assert.Panics(t,
-		func() { APIKeySecurity(name, "invalid") },
+		func() { swag.New(SecurityScheme("apikey", APIKeySecurity(name, "invalid"))) },
 		"expected APIKeySecurity to panic with invalid \"in\" parameter",
 	)
+
+	api := swag.New(Validating(), SecurityScheme("apikey", APIKeySecurity(name, "invalid")))
+	assert.NotContains(t, api.SecurityDefinitions, "apikey")
+	assert.EqualError(t, api.Err(), `build failed, APIKeySecurity "in" parameter must be one of: "header" or "query"`)
 }
 
 func TestOAuth2Security(t *testing.T) {
@@ -0,0 +1,225 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Forwarded represents the scheme, host and path prefix of a request as sent by the client,
+// before it has been rewritten by the reverse proxies
+type Forwarded struct {
+	Scheme string
+	Host   string
+	Prefix string
+}
+
+// Proxy derives the Forwarded information of a request from the RFC 7239 Forwarded header
+// and the X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Prefix headers,
+// the headers are only honored if the request comes from a trusted proxy
+type Proxy struct {
+	trustAll bool
+	trusted  []*net.IPNet
+	// protoOnly restricts the honored headers to X-Forwarded-Proto
+	protoOnly bool
+}
+
+// TrustAllProxies honors the forwarded headers of every request; since any client can then choose
+// the host and the base path written into the documents, it has to be configured explicitly
+var TrustAllProxies = &Proxy{trustAll: true}
+
+// defaultProxy is used by API.Handler and UIHandler unless another Proxy is configured,
+// it honors the X-Forwarded-Proto header of every request and ignores the host and prefix headers
+var defaultProxy = &Proxy{trustAll: true, protoOnly: true}
+
+// NewProxy returns a Proxy that only honors the forwarded headers of requests
+// coming from the IP addresses or CIDR ranges, e.g. "10.0.0.0/8" or "127.0.0.1"
+func NewProxy(trusted ...string) (*Proxy, error) {
+	p := &Proxy{}
+	for _, v := range trusted {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", v)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			v = fmt.Sprintf("%s/%d", v, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", v)
+		}
+		p.trusted = append(p.trusted, ipNet)
+	}
+	return p, nil
+}
+
+// Trusted reports whether the forwarded headers sent by the remote address, as in http.Request.RemoteAddr, are honored
+func (p *Proxy) Trusted(remoteAddr string) bool {
+	if p == nil {
+		return false
+	}
+	if p.trustAll {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range p.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the Forwarded information of the request; the Forwarded header takes precedence
+// over the X-Forwarded-* headers, and the values that are missing or invalid fall back to the request itself
+func (p *Proxy) Resolve(r *http.Request) Forwarded {
+	var result Forwarded
+	if p.Trusted(r.RemoteAddr) && p.protoOnly {
+		result.Scheme = firstHeaderValue(r.Header.Get("X-Forwarded-Proto"))
+	} else if p.Trusted(r.RemoteAddr) {
+		if v := r.Header.Get("Forwarded"); v != "" {
+			params := parseForwarded(v)
+			result.Scheme = params["proto"]
+			result.Host = params["host"]
+		}
+		if result.Scheme == "" {
+			result.Scheme = firstHeaderValue(r.Header.Get("X-Forwarded-Proto"))
+		}
+		if result.Host == "" {
+			result.Host = firstHeaderValue(r.Header.Get("X-Forwarded-Host"))
+		}
+		result.Prefix = firstHeaderValue(r.Header.Get("X-Forwarded-Prefix"))
+	}
+
+	result.Scheme = strings.ToLower(result.Scheme)
+	if result.Scheme != "http" && result.Scheme != "https" {
+		result.Scheme = ""
+		if r.TLS != nil {
+			result.Scheme = "https"
+		}
+	}
+	if result.Scheme == "" {
+		result.Scheme = r.URL.Scheme
+	}
+	if result.Scheme == "" {
+		result.Scheme = "http"
+	}
+	if !validForwardedHost(result.Host) {
+		result.Host = r.Host
+	}
+	result.Prefix = cleanForwardedPrefix(result.Prefix)
+	return result
+}
+
+// firstHeaderValue returns the value set by the proxy closest to the client in a comma separated list
+func firstHeaderValue(v string) string {
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
+
+// parseForwarded returns the parameters of the first element of a RFC 7239 Forwarded header,
+// the element added by the proxy closest to the client
+func parseForwarded(v string) map[string]string {
+	params := make(map[string]string)
+	var (
+		pair   strings.Builder
+		quoted bool
+	)
+	flush := func() {
+		kv := strings.SplitN(pair.String(), "=", 2)
+		pair.Reset()
+		if len(kv) != 2 {
+			return
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if _, ok := params[key]; !ok {
+			params[key] = value
+		}
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(v):
+			i++
+			c = v[i]
+		case c == ';' && !quoted:
+			flush()
+			continue
+		case c == ',' && !quoted:
+			flush()
+			return params
+		}
+		pair.WriteByte(c)
+	}
+	flush()
+	return params
+}
+
+// validForwardedHost reports whether v is a host with an optional port,
+// the forwarded host is written into the documents so it must not contain any other characters
+func validForwardedHost(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune(".-_:[]", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// cleanForwardedPrefix returns the cleaned path prefix, or an empty string if it is invalid
+func cleanForwardedPrefix(v string) string {
+	if v == "" || !strings.HasPrefix(v, "/") || strings.ContainsAny(v, "\"'<>\\`?#%") {
+		return ""
+	}
+	for _, c := range v {
+		if c <= ' ' || c == 0x7f {
+			return ""
+		}
+	}
+	v = path.Clean(v)
+	if v == "/" {
+		return ""
+	}
+	return v
+}
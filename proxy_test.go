@@ -0,0 +1,186 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProxy(t *testing.T) {
+	p, err := NewProxy("10.0.0.0/8", "192.168.1.1", "::1")
+	assert.Nil(t, err)
+	assert.True(t, p.Trusted("10.1.2.3:1234"))
+	assert.True(t, p.Trusted("192.168.1.1:80"))
+	assert.True(t, p.Trusted("[::1]:80"))
+	assert.False(t, p.Trusted("192.168.1.2:80"))
+	assert.False(t, p.Trusted("invalid"))
+
+	_, err = NewProxy("example.com")
+	assert.NotNil(t, err)
+	_, err = NewProxy("10.0.0.0/33")
+	assert.NotNil(t, err)
+}
+
+func TestProxy_Resolve(t *testing.T) {
+	trusted, _ := NewProxy("192.0.2.1")
+	tests := []struct {
+		name    string
+		proxy   *Proxy
+		headers map[string]string
+		want    Forwarded
+	}{
+		{
+			name:  "none",
+			proxy: TrustAllProxies,
+			want:  Forwarded{Scheme: "http", Host: "example.com"},
+		},
+		{
+			name:  "default",
+			proxy: defaultProxy,
+			headers: map[string]string{
+				"Forwarded":          "proto=http;host=api.example.org",
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "api.example.org",
+				"X-Forwarded-Prefix": "/petstore",
+			},
+			want: Forwarded{Scheme: "https", Host: "example.com"},
+		},
+		{
+			name:  "x-forwarded",
+			proxy: TrustAllProxies,
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "api.example.org, proxy.local",
+				"X-Forwarded-Prefix": "/petstore/",
+			},
+			want: Forwarded{Scheme: "https", Host: "api.example.org", Prefix: "/petstore"},
+		},
+		{
+			name:  "forwarded",
+			proxy: TrustAllProxies,
+			headers: map[string]string{
+				"Forwarded":         `for=192.0.2.60;proto=https;host="api.example.org:8443", for=198.51.100.17;host=proxy.local`,
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "other.example.org",
+			},
+			want: Forwarded{Scheme: "https", Host: "api.example.org:8443"},
+		},
+		{
+			name:  "untrusted",
+			proxy: &Proxy{},
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "api.example.org",
+				"X-Forwarded-Prefix": "/petstore",
+			},
+			want: Forwarded{Scheme: "http", Host: "example.com"},
+		},
+		{
+			name:  "trusted",
+			proxy: trusted,
+			headers: map[string]string{
+				"X-Forwarded-Host": "api.example.org",
+			},
+			want: Forwarded{Scheme: "http", Host: "api.example.org"},
+		},
+		{
+			name:  "invalid",
+			proxy: TrustAllProxies,
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "javascript",
+				"X-Forwarded-Host":   `evil"/>`,
+				"X-Forwarded-Prefix": `/"><script>`,
+			},
+			want: Forwarded{Scheme: "http", Host: "example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, tt.proxy.Resolve(r))
+		})
+	}
+}
+
+func TestAPI_HandlerForwarded(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Forwarded", "proto=https;host=api.example.org")
+	r.Header.Set("X-Forwarded-Prefix", "/petstore")
+	w := httptest.NewRecorder()
+	New().Handler(HandlerProxy(TrustAllProxies)).ServeHTTP(w, r)
+
+	var doc API
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "api.example.org", doc.Host)
+	assert.Equal(t, "/petstore", doc.BasePath)
+	assert.Equal(t, []string{"https"}, doc.Schemes)
+
+	// by default only X-Forwarded-Proto is honored
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	New().Handler().ServeHTTP(w, r)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "example.com", doc.Host)
+	assert.Equal(t, "/", doc.BasePath)
+	assert.Equal(t, []string{"https"}, doc.Schemes)
+
+	proxy, _ := NewProxy("10.0.0.0/8")
+	w = httptest.NewRecorder()
+	New().Handler(HandlerProxy(proxy)).ServeHTTP(w, r)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "example.com", doc.Host)
+	assert.Equal(t, "/", doc.BasePath)
+	assert.Equal(t, []string{"http"}, doc.Schemes)
+}
+
+func TestUIHandlerForwarded(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/swagger/ui/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "api.example.org")
+	r.Header.Set("X-Forwarded-Prefix", "/petstore")
+
+	w := httptest.NewRecorder()
+	UIHandler("/swagger/ui", "/swagger/json", false, UIProxy(TrustAllProxies)).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), `"/petstore/swagger/json"`)
+
+	w = httptest.NewRecorder()
+	UIHandler("/swagger/ui", "/swagger/json", true, UIProxy(TrustAllProxies)).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), `"https://api.example.org/petstore/swagger/json"`)
+
+	// by default only X-Forwarded-Proto is honored
+	w = httptest.NewRecorder()
+	UIHandler("/swagger/ui", "/swagger/json", true).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), `"https://example.com/swagger/json"`)
+
+	w = httptest.NewRecorder()
+	UIHandler("/swagger/ui", "/swagger/json", true, UIProxy(&Proxy{})).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), `"http://example.com/swagger/json"`)
+
+	r = httptest.NewRequest(http.MethodGet, "/swagger/ui", nil)
+	r.Header.Set("X-Forwarded-Prefix", "/petstore")
+	w = httptest.NewRecorder()
+	UIHandler("/swagger/ui", "/swagger/json", false, UIProxy(TrustAllProxies)).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/petstore/swagger/ui/", w.Header().Get("Location"))
+}
@@ -15,12 +15,65 @@
 package swag
 
 import (
+	"encoding"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/zc2638/swag/types"
 )
 
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+var (
+	oneOfMu    sync.RWMutex
+	oneOfTypes = make(map[string][]reflect.Type)
+)
+
+// RegisterOneOf registers under the name the concrete types allowed for the free-form fields,
+// i.e. declared as interface{} or json.RawMessage, tagged with oneof:"name"; e.g.
+//
+//	swag.RegisterOneOf("event", Created{}, Deleted{})
+//
+//	type Event struct {
+//		Payload json.RawMessage `json:"payload" oneof:"event"`
+//	}
+func RegisterOneOf(name string, prototypes ...interface{}) {
+	list := make([]reflect.Type, 0, len(prototypes))
+	for _, prototype := range prototypes {
+		list = append(list, reflect.TypeOf(prototype))
+	}
+
+	oneOfMu.Lock()
+	defer oneOfMu.Unlock()
+	oneOfTypes[name] = list
+}
+
+// oneOf returns the properties of the concrete types registered under the name
+func oneOf(name string) []Property {
+	oneOfMu.RLock()
+	defer oneOfMu.RUnlock()
+
+	var result []Property
+	for _, t := range oneOfTypes[name] {
+		result = append(result, inspect(t, ""))
+	}
+	return result
+}
+
+// freeForm returns the property of a free-form value, rendered by swagger 2.0 as an object with any properties
+func freeForm(t reflect.Type) Property {
+	return Property{
+		GoType:               t,
+		Type:                 "object",
+		AdditionalProperties: &Property{FreeForm: true},
+		FreeForm:             true,
+	}
+}
+
 func inspect(t reflect.Type, jsonTag string) Property {
 	p := Property{
 		GoType: t,
@@ -33,9 +86,21 @@ func inspect(t reflect.Type, jsonTag string) Property {
 
 	if p.GoType.Kind() == reflect.Ptr {
 		p.GoType = p.GoType.Elem()
+		p.Nullable = true
+	}
+
+	if p.GoType == rawMessageType {
+		return freeForm(p.GoType)
+	}
+	if known, ok := knownType(p.GoType); ok {
+		known.Nullable = known.Nullable || p.Nullable
+		return known
 	}
 
 	switch p.GoType.Kind() {
+	case reflect.Interface:
+		return freeForm(p.GoType)
+
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		p.Type = types.Integer.String()
 		p.Format = "int32"
@@ -67,45 +132,44 @@ func inspect(t reflect.Type, jsonTag string) Property {
 		name := makeName(p.GoType)
 		p.Ref = makeRef(name)
 
-	case reflect.Slice:
+	case reflect.Map:
+		if !isMapKey(p.GoType.Key()) {
+			break
+		}
+		value := inspect(p.GoType.Elem(), "")
+		p.Type = "object"
+		p.AdditionalProperties = &value
+		// the struct of the values, if any, is registered by define
+		p.GoType = value.GoType
+
+	case reflect.Slice, reflect.Array:
+		elem := inspect(p.GoType.Elem(), "")
 		p.Type = types.Array.String()
-		p.Items = &Items{}
-
-		p.GoType = t.Elem() // dereference the slice
-		switch p.GoType.Kind() {
-		case reflect.Ptr:
-			p.GoType = p.GoType.Elem()
-			name := makeName(p.GoType)
-			p.Items.Ref = makeRef(name)
-
-		case reflect.Struct:
-			name := makeName(p.GoType)
-			p.Items.Ref = makeRef(name)
-
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
-			p.Items.Type = types.Integer.String()
-			p.Items.Format = "int32"
-
-		case reflect.Int64, reflect.Uint64:
-			p.Items.Type = types.Integer.String()
-			p.Items.Format = "int64"
-
-		case reflect.Float64:
-			p.Items.Type = types.Number.String()
-			p.Items.Format = "double"
-
-		case reflect.Float32:
-			p.Items.Type = types.Number.String()
-			p.Items.Format = "float"
-
-		case reflect.String:
-			p.Items.Type = types.String.String()
+		p.Items = elem.items()
+		if p.GoType.Kind() == reflect.Array {
+			p.MinItems = p.GoType.Len()
+			p.MaxItems = p.GoType.Len()
 		}
+		// the struct of the elements, if any, is registered by define
+		p.GoType = elem.GoType
 	}
 
 	return p
 }
 
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// isMapKey reports whether the map key type is supported by encoding/json
+func isMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return t.Implements(textMarshalerType)
+}
+
 func buildProperty(t reflect.Type) (map[string]Property, []string) {
 	properties := make(map[string]Property)
 	required := make([]string, 0)
@@ -160,6 +224,9 @@ func buildProperty(t reflect.Type) (map[string]Property, []string) {
 		if enum := field.Tag.Get("enum"); enum != "" {
 			p.Enum = strings.Split(enum, ",")
 		}
+		if name := field.Tag.Get("oneof"); name != "" && p.FreeForm {
+			p.OneOf = oneOf(name)
+		}
 		properties[name] = p
 	}
 	return properties, required
@@ -174,7 +241,7 @@ func defineObject(v interface{}, desc string) Object {
 		t = reflect.TypeOf(v)
 	}
 
-	isArray := t.Kind() == reflect.Slice
+	isArray := t.Kind() == reflect.Slice && !isKnownType(t)
 	if isArray {
 		t = t.Elem()
 	}
@@ -182,7 +249,7 @@ func defineObject(v interface{}, desc string) Object {
 		t = t.Elem()
 	}
 
-	if t.Kind() != reflect.Struct {
+	if t.Kind() != reflect.Struct || isKnownType(t) {
 		p := inspect(t, "")
 		return Object{
 			IsArray: isArray,
@@ -209,7 +276,16 @@ func define(v interface{}) map[string]Object {
 	objMap := map[string]Object{}
 
 	obj := defineObject(v, "")
-	objMap[obj.Name] = obj
+	if isInlineObject(obj) {
+		// nested and fixed-size arrays, maps and the known types are described inline,
+		// only the structs of their elements are defined
+		for _, t := range inspect(obj.GoType, "").structTypes() {
+			child := defineObject(t, "")
+			objMap[child.Name] = child
+		}
+	} else {
+		objMap[obj.Name] = obj
+	}
 
 	dirty := true
 
@@ -217,10 +293,10 @@ func define(v interface{}) map[string]Object {
 		dirty = false
 		for _, d := range objMap {
 			for _, p := range d.Properties {
-				if p.GoType.Kind() == reflect.Struct {
-					name := makeName(p.GoType)
+				for _, t := range p.structTypes() {
+					name := makeName(t)
 					if _, exists := objMap[name]; !exists {
-						child := defineObject(p.GoType, p.Description)
+						child := defineObject(t, p.Description)
 						objMap[child.Name] = child
 						dirty = true
 					}
@@ -239,7 +315,19 @@ func MakeSchema(prototype interface{}) *Schema {
 	}
 
 	obj := defineObject(prototype, "")
-	if obj.IsArray {
+	if isInlineObject(obj) {
+		// nested and fixed-size arrays, maps and the known types are described inline
+		p := inspect(obj.GoType, "")
+		if obj.IsArray {
+			p = Property{Type: types.Array.String(), Items: p.items()}
+		}
+		schema.Ref = p.Ref
+		schema.Type = p.Type
+		schema.Format = p.Format
+		schema.Items = p.Items
+		schema.AdditionalProperties = p.AdditionalProperties
+
+	} else if obj.IsArray {
 		schema.Type = "array"
 		schema.Items = &Items{
 			Ref: makeRef(obj.Name),
@@ -251,3 +339,126 @@ func MakeSchema(prototype interface{}) *Schema {
 
 	return schema
 }
+
+// isInlineObject reports whether the object is described inline rather than by a definition
+func isInlineObject(obj Object) bool {
+	return obj.Type == types.Array.String() || obj.GoType.Kind() == reflect.Map || isKnownType(obj.GoType)
+}
+
+// checkObject returns an error for the object and for each property whose Go type has no representation
+// in the definition, they are rendered without type
+func checkObject(obj Object) []error {
+	if obj.Type == "" {
+		return []error{fmt.Errorf("unsupported type %v", obj.GoType)}
+	}
+
+	names := make([]string, 0, len(obj.Properties))
+	for name := range obj.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		p := obj.Properties[name]
+		switch {
+		case p.Type == "" && p.Ref == "":
+			errs = append(errs, fmt.Errorf("unsupported type %v of property %s.%s", p.GoType, obj.Name, name))
+		case p.Items != nil && !p.Items.supported():
+			errs = append(errs, fmt.Errorf("unsupported element type %v of property %s.%s", p.GoType, obj.Name, name))
+		case p.AdditionalProperties != nil && !p.AdditionalProperties.supported():
+			errs = append(errs, fmt.Errorf("unsupported value type %v of property %s.%s", p.GoType, obj.Name, name))
+		}
+	}
+	return errs
+}
+
+// supported reports whether the property, its items and its values all have a representation in the definition
+func (p Property) supported() bool {
+	if p.FreeForm {
+		return true
+	}
+	if p.Type == "" && p.Ref == "" {
+		return false
+	}
+	if p.Items != nil && !p.Items.supported() {
+		return false
+	}
+	return p.AdditionalProperties == nil || p.AdditionalProperties.supported()
+}
+
+// structTypes returns the struct types to define for the property, i.e. its own and the ones of its concrete types
+func (p Property) structTypes() []reflect.Type {
+	var result []reflect.Type
+	if p.GoType != nil && p.GoType.Kind() == reflect.Struct && !isKnownType(p.GoType) {
+		result = append(result, p.GoType)
+	}
+	for _, v := range p.OneOf {
+		result = append(result, v.structTypes()...)
+	}
+	return result
+}
+
+// items returns the property as the items of an array
+func (p Property) items() *Items {
+	return &Items{
+		Type:                 p.Type,
+		Format:               p.Format,
+		Enum:                 p.Enum,
+		Ref:                  p.Ref,
+		Items:                p.Items,
+		MinItems:             p.MinItems,
+		MaxItems:             p.MaxItems,
+		Minimum:              p.Minimum,
+		Maximum:              p.Maximum,
+		Default:              p.Default,
+		Pattern:              p.Pattern,
+		MinLength:            p.MinLength,
+		MaxLength:            p.MaxLength,
+		Required:             p.Required,
+		Properties:           p.Properties,
+		AdditionalProperties: p.AdditionalProperties,
+		FreeForm:             p.FreeForm,
+	}
+}
+
+// property returns the items of an array as a property
+func (i *Items) property() Property {
+	return Property{
+		Type:                 i.Type,
+		Format:               i.Format,
+		Enum:                 i.Enum,
+		Ref:                  i.Ref,
+		Items:                i.Items,
+		MinItems:             i.MinItems,
+		MaxItems:             i.MaxItems,
+		Minimum:              i.Minimum,
+		Maximum:              i.Maximum,
+		Default:              i.Default,
+		Pattern:              i.Pattern,
+		MinLength:            i.MinLength,
+		MaxLength:            i.MaxLength,
+		Required:             i.Required,
+		Properties:           i.Properties,
+		AdditionalProperties: i.AdditionalProperties,
+		FreeForm:             i.FreeForm,
+	}
+}
+
+// property returns the definition as a property, to describe its value like the one of a property
+func (o Object) property() Property {
+	return Property{
+		Type:                 o.Type,
+		Description:          o.Description,
+		Format:               o.Format,
+		Enum:                 o.Enum,
+		Items:                o.Items,
+		Required:             o.Required,
+		Properties:           o.Properties,
+		AdditionalProperties: o.AdditionalProperties,
+	}
+}
+
+func (i *Items) supported() bool {
+	return i.property().supported()
+}
@@ -18,8 +18,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"net"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -118,14 +122,9 @@ func TestNotStructDefine(t *testing.T) {
 	assert.Equal(t, "integer", obj.Type)
 	assert.Equal(t, "int32", obj.Format)
 
+	// byte slices are base64 strings described inline
 	v = define([]byte{1, 2})
-	obj, ok = v["uint8"]
-	if !assert.True(t, ok) {
-		fmt.Printf("%v", v)
-	}
-	assert.True(t, obj.IsArray)
-	assert.Equal(t, "integer", obj.Type)
-	assert.Equal(t, "int32", obj.Format)
+	assert.Empty(t, v)
 }
 
 func TestHonorJsonIgnore(t *testing.T) {
@@ -143,3 +142,213 @@ func TestMakeSchemaType(t *testing.T) {
 	objSchema := MakeSchema(struct{}{})
 	assert.Equal(t, "", objSchema.Type, "expect array type but get %s", objSchema.Type)
 }
+
+type mapOwner struct {
+	Name string `json:"name"`
+}
+
+type mapPet struct {
+	Scores  map[string]int                `json:"scores"`
+	Owners  map[string]*mapOwner          `json:"owners"`
+	Lists   map[string][]string           `json:"lists"`
+	Nested  map[string]map[string]float64 `json:"nested"`
+	ByID    map[int]mapOwner              `json:"byId"`
+	Invalid map[[2]int]string             `json:"invalid"`
+}
+
+func TestDefineMap(t *testing.T) {
+	v := define(mapPet{})
+	owner := makeName(reflect.TypeOf(mapOwner{}))
+	assert.Contains(t, v, owner, "the struct of the values is defined")
+
+	obj := v[makeName(reflect.TypeOf(mapPet{}))]
+	scores := obj.Properties["scores"]
+	assert.Equal(t, "object", scores.Type)
+	assert.Equal(t, "integer", scores.AdditionalProperties.Type)
+	assert.Equal(t, "int32", scores.AdditionalProperties.Format)
+
+	owners := obj.Properties["owners"]
+	assert.Equal(t, "object", owners.Type)
+	assert.Equal(t, makeRef(owner), owners.AdditionalProperties.Ref)
+	assert.True(t, owners.AdditionalProperties.Nullable)
+
+	lists := obj.Properties["lists"]
+	assert.Equal(t, "array", lists.AdditionalProperties.Type)
+	assert.Equal(t, "string", lists.AdditionalProperties.Items.Type)
+
+	nested := obj.Properties["nested"]
+	assert.Equal(t, "object", nested.AdditionalProperties.Type)
+	assert.Equal(t, "number", nested.AdditionalProperties.AdditionalProperties.Type)
+
+	assert.Equal(t, makeRef(owner), obj.Properties["byId"].AdditionalProperties.Ref)
+	assert.Nil(t, obj.Properties["invalid"].AdditionalProperties)
+	assert.Len(t, checkObject(obj), 1)
+
+	data, err := json.Marshal(obj.Properties["owners"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"$ref":"#/definitions/`+owner+`"}}`, string(data))
+}
+
+func TestMakeSchemaMap(t *testing.T) {
+	owner := makeName(reflect.TypeOf(mapOwner{}))
+
+	schema := MakeSchema(map[string]mapOwner{})
+	assert.Equal(t, "object", schema.Type)
+	assert.Empty(t, schema.Ref)
+	assert.Equal(t, makeRef(owner), schema.AdditionalProperties.Ref)
+	assert.Equal(t, []string{owner}, keys(define(map[string]mapOwner{})))
+
+	schema = MakeSchema([]map[string]int{})
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "object", schema.Items.Type)
+	assert.Equal(t, "integer", schema.Items.AdditionalProperties.Type)
+	assert.Empty(t, define([]map[string]int{}))
+
+	api := New()
+	api.AddEndpoint(&Endpoint{
+		Method:    "GET",
+		Path:      "/owners",
+		Responses: map[string]Response{"200": {Description: "ok", Schema: MakeSchema(map[string]*mapOwner{})}},
+	})
+	assert.Equal(t, []string{owner}, keys(api.Definitions))
+}
+
+type freeFormCreated struct {
+	ID string `json:"id" required:""`
+}
+
+type freeFormDeleted struct {
+	Reason string `json:"reason" required:""`
+}
+
+type freeFormEvent struct {
+	Payload  json.RawMessage        `json:"payload" oneof:"freeFormEvent"`
+	Metadata interface{}            `json:"metadata"`
+	Labels   map[string]interface{} `json:"labels"`
+	Raw      []json.RawMessage      `json:"raw"`
+	Values   []interface{}          `json:"values"`
+}
+
+func TestDefineFreeForm(t *testing.T) {
+	RegisterOneOf("freeFormEvent", freeFormCreated{}, &freeFormDeleted{})
+
+	v := define(freeFormEvent{})
+	created := makeName(reflect.TypeOf(freeFormCreated{}))
+	deleted := makeName(reflect.TypeOf(freeFormDeleted{}))
+	assert.Contains(t, v, created, "the concrete types are defined")
+	assert.Contains(t, v, deleted, "the concrete types are defined")
+
+	obj := v[makeName(reflect.TypeOf(freeFormEvent{}))]
+	assert.Empty(t, checkObject(obj))
+
+	payload := obj.Properties["payload"]
+	assert.True(t, payload.FreeForm)
+	assert.Equal(t, []string{makeRef(created), makeRef(deleted)}, []string{payload.OneOf[0].Ref, payload.OneOf[1].Ref})
+
+	data, err := json.Marshal(obj.Properties["metadata"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{}}`, string(data))
+
+	data, err = json.Marshal(obj.Properties["labels"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"type":"object","additionalProperties":{}}}`, string(data))
+
+	assert.True(t, obj.Properties["raw"].Items.FreeForm)
+	assert.True(t, obj.Properties["values"].Items.FreeForm)
+}
+
+type arrayPet struct {
+	Matrix      [][]string         `json:"matrix"`
+	Counters    []map[string]int   `json:"counters"`
+	Coordinates [2]float64         `json:"coordinates"`
+	Grid        [][3]int64         `json:"grid"`
+	Families    [][]*mapOwner      `json:"families"`
+	Pointer     *[]string          `json:"pointer"`
+	Nested      map[string][][]int `json:"nested"`
+}
+
+func TestDefineArray(t *testing.T) {
+	v := define(arrayPet{})
+	owner := makeName(reflect.TypeOf(mapOwner{}))
+	assert.Contains(t, v, owner, "the struct of the nested elements is defined")
+
+	obj := v[makeName(reflect.TypeOf(arrayPet{}))]
+	assert.Empty(t, checkObject(obj))
+
+	tests := map[string]string{
+		"matrix":      `{"type":"array","items":{"type":"array","items":{"type":"string"}}}`,
+		"counters":    `{"type":"array","items":{"type":"object","additionalProperties":{"type":"integer","format":"int32"}}}`,
+		"coordinates": `{"type":"array","items":{"type":"number","format":"double"},"minItems":2,"maxItems":2}`,
+		"grid":        `{"type":"array","items":{"type":"array","items":{"type":"integer","format":"int64"},"minItems":3,"maxItems":3}}`,
+		"families":    `{"type":"array","items":{"type":"array","items":{"$ref":"#/definitions/` + owner + `"}}}`,
+		"pointer":     `{"type":"array","items":{"type":"string"}}`,
+		"nested":      `{"type":"object","additionalProperties":{"type":"array","items":{"type":"array","items":{"type":"integer","format":"int32"}}}}`,
+	}
+	for name, expected := range tests {
+		data, err := json.Marshal(obj.Properties[name])
+		assert.Nil(t, err)
+		assert.JSONEq(t, expected, string(data), name)
+	}
+
+	schema := MakeSchema([][]mapOwner{})
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "array", schema.Items.Type)
+	assert.Equal(t, makeRef(owner), schema.Items.Items.Ref)
+	assert.Equal(t, []string{owner}, keys(define([][]mapOwner{})))
+
+	schema = MakeSchema([4]string{})
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "string", schema.Items.Type)
+	assert.Empty(t, define([4]string{}))
+}
+
+type stdlibPet struct {
+	Born    time.Time            `json:"born"`
+	Died    *time.Time           `json:"died"`
+	Age     time.Duration        `json:"age"`
+	AgeText time.Duration        `json:"ageText,string"`
+	Photo   []byte               `json:"photo"`
+	Address net.IP               `json:"address"`
+	Chip    *big.Int             `json:"chip"`
+	Visits  []time.Time          `json:"visits"`
+	Photos  map[string][]byte    `json:"photos"`
+	Meals   map[string]time.Time `json:"meals"`
+}
+
+func TestDefineStdlib(t *testing.T) {
+	v := define(stdlibPet{})
+	assert.Len(t, v, 1, "the standard library types are described inline")
+
+	obj := v[makeName(reflect.TypeOf(stdlibPet{}))]
+	assert.Empty(t, checkObject(obj))
+
+	tests := map[string]string{
+		"born":    `{"type":"string","format":"date-time"}`,
+		"died":    `{"type":"string","format":"date-time"}`,
+		"age":     `{"type":"integer","format":"int64"}`,
+		"ageText": `{"type":"string"}`,
+		"photo":   `{"type":"string","format":"byte"}`,
+		"address": `{"type":"string"}`,
+		"chip":    `{"type":"integer"}`,
+		"visits":  `{"type":"array","items":{"type":"string","format":"date-time"}}`,
+		"photos":  `{"type":"object","additionalProperties":{"type":"string","format":"byte"}}`,
+		"meals":   `{"type":"object","additionalProperties":{"type":"string","format":"date-time"}}`,
+	}
+	for name, expected := range tests {
+		data, err := json.Marshal(obj.Properties[name])
+		assert.Nil(t, err)
+		assert.JSONEq(t, expected, string(data), name)
+	}
+	assert.True(t, obj.Properties["died"].Nullable)
+
+	schema := MakeSchema(time.Time{})
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time", Prototype: time.Time{}}, schema)
+	schema = MakeSchema([]time.Time{})
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, &Items{Type: "string", Format: "date-time"}, schema.Items)
+	schema = MakeSchema([]byte{})
+	assert.Equal(t, "string", schema.Type)
+	assert.Equal(t, "byte", schema.Format)
+	assert.Empty(t, define(time.Time{}))
+	assert.Empty(t, define([]time.Time{}))
+}
@@ -0,0 +1,229 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrDuplicateEndpoint is wrapped by the error raised when an endpoint is registered twice with DuplicateError
+var ErrDuplicateEndpoint = errors.New("duplicate endpoint")
+
+// DuplicatePolicy decides how AddEndpoint handles an endpoint whose method and path are already registered
+type DuplicatePolicy int
+
+const (
+	// DuplicateOverwrite replaces the registered endpoint, this is the default
+	DuplicateOverwrite DuplicatePolicy = iota
+	// DuplicateKeepFirst keeps the registered endpoint and ignores the new one
+	DuplicateKeepFirst
+	// DuplicateError panics with an error wrapping ErrDuplicateEndpoint, no endpoint of the call is registered
+	DuplicateError
+)
+
+// SetDuplicatePolicy sets the policy applied by AddEndpoint to the endpoints that are already registered
+func (a *API) SetDuplicatePolicy(policy DuplicatePolicy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.duplicates = policy
+}
+
+// ReplaceEndpoint adds the specified endpoints like AddEndpoint, replacing the endpoints
+// registered with the same method and path whatever the duplicate policy;
+// the definitions only used by the replaced endpoints are removed
+func (a *API) ReplaceEndpoint(es ...*Endpoint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	a.addEndpoints(es, DuplicateOverwrite)
+}
+
+// RemoveEndpoint removes the endpoint registered with the method and path, the path is the key of API.Paths;
+// the definitions only used by the removed endpoint are removed too.
+// It reports whether the endpoint was registered
+func (a *API) RemoveEndpoint(method, path string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	endpoints, ok := a.Paths[path]
+	if !ok {
+		return false
+	}
+	e := endpoints.endpoint(method)
+	if e == nil {
+		return false
+	}
+
+	defer a.modified()
+	endpoints.set(method, nil)
+	if endpoints.empty() {
+		delete(a.Paths, path)
+	}
+	a.pruneDefinitions(e)
+	return true
+}
+
+// RemovePath removes all the endpoints registered with the path, the path is the key of API.Paths;
+// the definitions only used by the removed endpoints are removed too.
+// It reports whether the path was registered
+func (a *API) RemovePath(path string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	endpoints, ok := a.Paths[path]
+	if !ok {
+		return false
+	}
+
+	defer a.modified()
+	delete(a.Paths, path)
+	var removed []*Endpoint
+	endpoints.Walk(func(e *Endpoint) {
+		removed = append(removed, e)
+	})
+	a.pruneDefinitions(removed...)
+	return true
+}
+
+// lookup returns the endpoint registered with the method and path
+func (a *API) lookup(method, path string) *Endpoint {
+	endpoints, ok := a.Paths[path]
+	if !ok {
+		return nil
+	}
+	return endpoints.endpoint(method)
+}
+
+// checkDuplicates returns an error if one of the endpoints, with the path prefix, is already registered
+// or is registered twice
+func (a *API) checkDuplicates(es []*Endpoint, prefix string) error {
+	seen := make(map[string]struct{}, len(es))
+	for _, e := range es {
+		p := path.Join(prefix, e.Path)
+		key := strings.ToUpper(e.Method) + " " + p
+		if _, ok := seen[key]; ok || a.lookup(e.Method, p) != nil {
+			return fmt.Errorf("%w, %s", ErrDuplicateEndpoint, key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// pruneDefinitions removes the definitions referenced by the removed endpoints
+// that are no longer referenced by the registered endpoints
+func (a *API) pruneDefinitions(removed ...*Endpoint) {
+	candidates := make(map[string]struct{})
+	for _, e := range removed {
+		a.endpointRefs(e, candidates)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	used := make(map[string]struct{})
+	for _, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			a.endpointRefs(e, used)
+		})
+	}
+	for name := range candidates {
+		if _, ok := used[name]; !ok {
+			delete(a.Definitions, name)
+		}
+	}
+}
+
+// endpointRefs collects the names of the definitions referenced by the endpoint, directly or not
+func (a *API) endpointRefs(e *Endpoint, refs map[string]struct{}) {
+	for _, p := range e.Parameters {
+		a.schemaRefs(p.Schema, refs)
+	}
+	for _, resp := range e.Responses {
+		a.schemaRefs(resp.Schema, refs)
+	}
+}
+
+func (a *API) schemaRefs(s *Schema, refs map[string]struct{}) {
+	for _, ref := range s.refs() {
+		a.refs(ref, refs)
+	}
+}
+
+func (a *API) refs(ref string, refs map[string]struct{}) {
+	name, ok := refName(ref)
+	if !ok {
+		return
+	}
+	if _, ok := refs[name]; ok {
+		return
+	}
+	refs[name] = struct{}{}
+
+	for _, ref := range a.Definitions[name].property().refs() {
+		a.refs(ref, refs)
+	}
+}
+
+// refName returns the name of the definition referenced by ref, if it references one
+func refName(ref string) (string, bool) {
+	const prefix = "#/definitions/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, prefix), true
+}
+
+// refs returns the references held directly by the schema
+func (s *Schema) refs() []string {
+	if s == nil {
+		return nil
+	}
+	refs := []string{s.Ref}
+	if s.Items != nil {
+		refs = append(refs, s.Items.refs()...)
+	}
+	if s.AdditionalProperties != nil {
+		refs = append(refs, s.AdditionalProperties.refs()...)
+	}
+	return refs
+}
+
+// refs returns the references held directly by the property
+func (p Property) refs() []string {
+	refs := []string{p.Ref}
+	if p.Items != nil {
+		refs = append(refs, p.Items.refs()...)
+	}
+	if p.AdditionalProperties != nil {
+		refs = append(refs, p.AdditionalProperties.refs()...)
+	}
+	for _, v := range p.OneOf {
+		refs = append(refs, v.refs()...)
+	}
+	for _, v := range p.Properties {
+		refs = append(refs, v.refs()...)
+	}
+	return refs
+}
+
+// refs returns the references held directly by the items
+func (i *Items) refs() []string {
+	return i.property().refs()
+}
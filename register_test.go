@@ -0,0 +1,135 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registerPet struct {
+	Name  string        `json:"name"`
+	Owner registerOwner `json:"owner"`
+}
+
+type registerOwner struct {
+	Name string `json:"name"`
+}
+
+type registerDog struct {
+	Name string `json:"name"`
+}
+
+func TestAPI_DuplicatePolicy(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Summary: "list"})
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Summary: "overwrite"})
+	assert.Equal(t, "overwrite", api.Paths["/pets"].Get.Summary)
+
+	api.SetDuplicatePolicy(DuplicateKeepFirst)
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Summary: "keep"})
+	assert.Equal(t, "overwrite", api.Paths["/pets"].Get.Summary)
+
+	api.SetDuplicatePolicy(DuplicateError)
+	func() {
+		defer func() {
+			err, ok := recover().(error)
+			assert.True(t, ok)
+			assert.True(t, errors.Is(err, ErrDuplicateEndpoint))
+			assert.EqualError(t, err, "duplicate endpoint, PUT /cats")
+		}()
+		api.AddEndpoint(
+			&Endpoint{Method: http.MethodPut, Path: "/cats"},
+			&Endpoint{Method: http.MethodPut, Path: "/cats"},
+		)
+	}()
+	assert.NotContains(t, api.Paths, "/cats", "no endpoint of the call must be registered")
+
+	api.ReplaceEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Summary: "replace"})
+	assert.Equal(t, "replace", api.Paths["/pets"].Get.Summary)
+}
+
+func TestAPI_RemoveEndpoint(t *testing.T) {
+	api := New()
+	api.AddEndpoint(
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/pets",
+			Responses: map[string]Response{"200": {Schema: MakeSchema([]registerPet{})}},
+		},
+		&Endpoint{
+			Method:     http.MethodPost,
+			Path:       "/pets",
+			Parameters: []Parameter{{In: "body", Name: "body", Schema: MakeSchema(registerPet{})}},
+		},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/dogs",
+			Responses: map[string]Response{"200": {Schema: MakeSchema(registerDog{})}},
+		},
+	)
+	pet := makeName(reflect.TypeOf(registerPet{}))
+	owner := makeName(reflect.TypeOf(registerOwner{}))
+	dog := makeName(reflect.TypeOf(registerDog{}))
+	assert.Len(t, api.Definitions, 3)
+
+	assert.False(t, api.RemoveEndpoint(http.MethodDelete, "/pets"))
+	assert.False(t, api.RemoveEndpoint(http.MethodGet, "/cats"))
+
+	assert.True(t, api.RemoveEndpoint(http.MethodGet, "/pets"))
+	assert.Nil(t, api.Paths["/pets"].Get)
+	assert.Contains(t, api.Definitions, pet, "the definition is still used by POST /pets")
+	assert.Contains(t, api.Definitions, owner)
+
+	assert.True(t, api.RemoveEndpoint("post", "/pets"))
+	assert.NotContains(t, api.Paths, "/pets")
+	assert.NotContains(t, api.Definitions, pet)
+	assert.NotContains(t, api.Definitions, owner)
+	assert.Contains(t, api.Definitions, dog)
+
+	assert.True(t, api.RemovePath("/dogs"))
+	assert.False(t, api.RemovePath("/dogs"))
+	assert.Empty(t, api.Paths)
+	assert.Empty(t, api.Definitions)
+}
+
+func TestAPI_ReplaceEndpointPrune(t *testing.T) {
+	api := New()
+	api.AddEndpoint(
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/pets",
+			Responses: map[string]Response{"200": {Schema: MakeSchema([]registerPet{})}},
+		},
+		&Endpoint{
+			Method:    http.MethodGet,
+			Path:      "/dogs",
+			Responses: map[string]Response{"200": {Schema: MakeSchema(registerDog{})}},
+		},
+	)
+	dog := makeName(reflect.TypeOf(registerDog{}))
+
+	api.ReplaceEndpoint(&Endpoint{
+		Method:    http.MethodGet,
+		Path:      "/dogs",
+		Responses: map[string]Response{"200": {Description: "ok"}},
+	})
+	assert.NotContains(t, api.Definitions, dog)
+	assert.Len(t, api.Definitions, 2)
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/zc2638/swag/types"
+)
+
+// Router returns a http.Handler that dispatches the requests to the endpoints of the API;
+// the path parameters declared as {name} segments are added to the request context
+// and can be read by types.URLParam. The handlers are read at each request, so the handlers
+// replaced later, e.g. by Bind or by API.Walk before serving, are dispatched to
+func (a *API) Router() http.Handler {
+	return &router{api: a}
+}
+
+type router struct {
+	api *API
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	segments := splitPath(req.URL.EscapedPath())
+	for i, segment := range segments {
+		if v, err := url.PathUnescape(segment); err == nil {
+			segments[i] = v
+		}
+	}
+
+	var (
+		found      bool
+		bestPath   string
+		bestParams map[string]string
+		bestRoute  []string
+	)
+	doc := rt.api.snapshot()
+	for rawPath := range doc.Paths {
+		route := splitPath(path.Join(doc.BasePath, rawPath))
+		params, ok := matchRoute(route, segments)
+		if !ok {
+			continue
+		}
+		if !found || preferRoute(route, bestRoute) {
+			found, bestPath, bestParams, bestRoute = true, rawPath, params, route
+		}
+	}
+
+	// the snapshot only matches the route, the handlers are read from the live endpoints
+	// so that the handlers replaced after the first request, e.g. by API.Walk, are served
+	var best *Endpoints
+	if found {
+		rt.api.mu.RLock()
+		best = rt.api.Paths[bestPath].shallowCopy()
+		rt.api.mu.RUnlock()
+	}
+	if best == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	if len(bestParams) > 0 {
+		req = req.WithContext(types.AddURLParamsToContext(req.Context(), bestParams))
+	}
+	best.ServeHTTP(w, req)
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func isParamSegment(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// matchRoute matches the path segments against the route template and returns the path parameters
+func matchRoute(route, segments []string) (map[string]string, bool) {
+	if len(route) != len(segments) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, segment := range route {
+		if !isParamSegment(segment) {
+			if segment != segments[i] {
+				return nil, false
+			}
+			continue
+		}
+		if segments[i] == "" {
+			return nil, false
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[segment[1:len(segment)-1]] = segments[i]
+	}
+	return params, true
+}
+
+// preferRoute reports whether route is more specific than other,
+// i.e. it has a static segment where other has a parameter first
+func preferRoute(route, other []string) bool {
+	for i := range route {
+		a, b := isParamSegment(route[i]), isParamSegment(other[i])
+		if a != b {
+			return b
+		}
+	}
+	return strings.Join(route, "/") < strings.Join(other, "/")
+}
@@ -0,0 +1,95 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zc2638/swag/types"
+)
+
+func TestAPI_Router(t *testing.T) {
+	handler := func(name string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name+":"+types.URLParam(r, "id")+":"+types.URLParam(r, "photo"))
+		}
+	}
+
+	api := New()
+	api.BasePath = "/api"
+	api.AddEndpoint(
+		&Endpoint{Method: http.MethodGet, Path: "/pets", Handler: handler("list")},
+		&Endpoint{Method: http.MethodGet, Path: "/pets/{id}", Handler: handler("get")},
+		&Endpoint{Method: http.MethodDelete, Path: "/pets/{id}", Handler: handler("delete")},
+		&Endpoint{Method: http.MethodGet, Path: "/pets/mine", Handler: handler("mine")},
+		&Endpoint{Method: http.MethodGet, Path: "/pets/{id}/photos/{photo}", Handler: handler("photo")},
+	)
+	router := api.Router()
+
+	tests := []struct {
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{method: http.MethodGet, target: "/api/pets", wantCode: http.StatusOK, wantBody: "list::"},
+		{method: http.MethodGet, target: "/api/pets/", wantCode: http.StatusOK, wantBody: "list::"},
+		{method: http.MethodGet, target: "/api/pets/1", wantCode: http.StatusOK, wantBody: "get:1:"},
+		{method: http.MethodDelete, target: "/api/pets/1", wantCode: http.StatusOK, wantBody: "delete:1:"},
+		{method: http.MethodGet, target: "/api/pets/mine", wantCode: http.StatusOK, wantBody: "mine::"},
+		{method: http.MethodGet, target: "/api/pets/a%2Fb/photos/2", wantCode: http.StatusOK, wantBody: "photo:a/b:2"},
+		{method: http.MethodGet, target: "/pets/1", wantCode: http.StatusNotFound},
+		{method: http.MethodGet, target: "/api/pets/1/photos", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPI_RouterWalk(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{Method: http.MethodGet, Path: "/pets", Handler: func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "list")
+	}})
+	router := api.Router()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+	assert.Equal(t, "list", w.Body.String())
+
+	// the handlers wrapped after the first request are served
+	api.Walk(func(_ string, e *Endpoint) {
+		next := e.Handler.(func(w http.ResponseWriter, r *http.Request))
+		e.Handler = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "wrapped ")
+			next(w, r)
+		}
+	})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+	assert.Equal(t, "wrapped list", w.Body.String())
+}
@@ -0,0 +1,638 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zc2638/swag/types"
+)
+
+// DefaultMaxBodySize is the default size limit of the request bodies read by the request validation
+const DefaultMaxBodySize int64 = 32 << 20
+
+// ValidationError represents a single violation of the swagger definition
+type ValidationError struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+
+	// status overrides the status code of the rejected request, e.g. 413 for a body over the size limit
+	status int
+}
+
+func (e ValidationError) Error() string {
+	if e.Name == "" {
+		return e.In + ": " + e.Message
+	}
+	return e.In + " " + e.Name + ": " + e.Message
+}
+
+// ValidationErrors represents all the violations of the swagger definition found at once
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, v := range e {
+		messages = append(messages, v.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// SetMaxBodySize sets the size limit of the request bodies read by the request validation,
+// a larger body is a violation rejected with a 413 status; defaults to DefaultMaxBodySize,
+// a negative size disables the limit
+func (a *API) SetMaxBodySize(n int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.maxBodySize = n
+}
+
+// ValidateRequests wraps the handler of every endpoint added so far with RequestValidation
+func (a *API) ValidateRequests() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	for _, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if h, ok := httpHandler(e.Handler); ok {
+				e.Handler = a.RequestValidation(e)(h)
+			}
+		})
+	}
+}
+
+// RequestValidation returns a middleware that checks the requests against the parameters declared by the endpoint,
+// the request is rejected with a 400 status and a json body listing every violation if any;
+// the status is 413 if the body or the form is larger than the limit set by SetMaxBodySize
+func (a *API) RequestValidation(e *Endpoint) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			errs := a.validateRequest(e, w, r)
+			if len(errs) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			status := http.StatusBadRequest
+			for _, err := range errs {
+				if err.status != 0 {
+					status = err.status
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "request validation failed",
+				"errors":  errs,
+			})
+		})
+	}
+}
+
+// ValidateRequest checks the request against the parameters declared by the endpoint and returns all the violations;
+// path parameters are only checked if they have been added to the request context by types.AddURLParamsToContext
+func (a *API) ValidateRequest(e *Endpoint, r *http.Request) ValidationErrors {
+	return a.validateRequest(e, nil, r)
+}
+
+// validateRequest is ValidateRequest, w is told to close the connection if the body is over the size limit
+func (a *API) validateRequest(e *Endpoint, w http.ResponseWriter, r *http.Request) ValidationErrors {
+	a.mu.RLock()
+	limit := a.maxBodySize
+	a.mu.RUnlock()
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	snapshot := a.snapshot()
+	v := &validator{definitions: snapshot.Definitions, w: w, maxBodySize: limit}
+	routeCtx, _ := r.Context().Value(types.RouteContextKey).(*types.Context)
+
+	var errs ValidationErrors
+	for _, p := range snapshot.Paths[e.Path].parameters(e) {
+		switch p.In {
+		case "path":
+			if routeCtx == nil {
+				continue
+			}
+			value, ok := routeCtx.PathParams[p.Name]
+			errs = append(errs, v.parameter(p, []string{value}, ok)...)
+		case "query":
+			values, ok := r.URL.Query()[p.Name]
+			errs = append(errs, v.parameter(p, values, ok)...)
+		case "header":
+			values, ok := r.Header[http.CanonicalHeaderKey(p.Name)]
+			errs = append(errs, v.parameter(p, values, ok)...)
+		case "formData":
+			errs = append(errs, v.formData(p, r)...)
+		case "body":
+			errs = append(errs, v.body(p, r)...)
+		}
+	}
+	return errs
+}
+
+// ResponseReporter receives the violations found in a response by ResponseValidation,
+// e.g. to log them, to fail a test or to increase a metric
+type ResponseReporter func(r *http.Request, e *Endpoint, errs ValidationErrors)
+
+// ValidateResponses wraps the handler of every endpoint added so far with ResponseValidation
+func (a *API) ValidateResponses(report ResponseReporter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.modified()
+
+	for _, endpoints := range a.Paths {
+		endpoints.Walk(func(e *Endpoint) {
+			if h, ok := httpHandler(e.Handler); ok {
+				e.Handler = a.ResponseValidation(e, report)(h)
+			}
+		})
+	}
+}
+
+// ResponseValidation returns a middleware that captures the responses and checks them against the responses declared
+// by the endpoint, the violations are passed to report; the response itself is sent unchanged
+func (a *API) ResponseValidation(e *Endpoint, report ResponseReporter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseCapture{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if errs := a.ValidateResponse(e, status, w.Header(), rw.body.Bytes()); len(errs) > 0 {
+				report(r, e, errs)
+			}
+		})
+	}
+}
+
+// ValidateResponse checks the status code, headers and body of a response against the responses declared by
+// the endpoint and returns all the violations; the body is only checked if it is json
+func (a *API) ValidateResponse(e *Endpoint, status int, header http.Header, body []byte) ValidationErrors {
+	code := strconv.Itoa(status)
+	resp, ok := e.Responses[code]
+	if !ok {
+		resp, ok = e.Responses["default"]
+	}
+	if !ok {
+		return ValidationErrors{{In: "status", Name: code, Message: "is not declared"}}
+	}
+
+	var errs ValidationErrors
+	for name := range resp.Headers {
+		if header.Get(name) == "" {
+			errs = append(errs, ValidationError{In: "header", Name: name, Message: "is required"})
+		}
+	}
+	if resp.Schema == nil || !isJSONMediaType(header.Get("Content-Type")) {
+		return errs
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return append(errs, ValidationError{In: "body", Message: "is required"})
+	}
+	v := &validator{definitions: a.snapshot().Definitions}
+	return append(errs, v.json("body", body, resp.Schema)...)
+}
+
+// responseCapture records the status code and the body written by a handler
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseCapture) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseCapture) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseCapture) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type validator struct {
+	definitions map[string]Object
+	// w and maxBodySize limit the size of the request bodies, a negative size disables the limit
+	w           http.ResponseWriter
+	maxBodySize int64
+	// formTooLarge reports whether the form of the request is over the size limit, it is reported once
+	formTooLarge bool
+}
+
+func (v *validator) parameter(p Parameter, values []string, ok bool) ValidationErrors {
+	if !ok || len(values) == 0 || (len(values) == 1 && values[0] == "" && p.Type != types.String) {
+		if p.Required {
+			return ValidationErrors{{In: p.In, Name: p.Name, Message: "is required"}}
+		}
+		return nil
+	}
+	typ, enum, minimum, maximum := p.Type.String(), p.Enum, p.Minimum, p.Maximum
+	if p.Type == types.Array {
+		values = splitCollection(values, p.CollectionFormat)
+		if p.Items != nil {
+			typ, enum, minimum, maximum = p.Items.Type, p.Items.Enum, p.Items.Minimum, p.Items.Maximum
+		}
+	} else if len(values) > 1 {
+		values = values[:1]
+	}
+
+	var errs ValidationErrors
+	for _, value := range values {
+		if msg := checkPrimitive(typ, value); msg != "" {
+			errs = append(errs, ValidationError{In: p.In, Name: p.Name, Message: msg})
+			continue
+		}
+		if msg := checkEnum(enum, value); msg != "" {
+			errs = append(errs, ValidationError{In: p.In, Name: p.Name, Message: msg})
+			continue
+		}
+		if msg := checkRange(minimum, maximum, value); msg != "" {
+			errs = append(errs, ValidationError{In: p.In, Name: p.Name, Message: msg})
+		}
+	}
+	return errs
+}
+
+// splitCollection splits the values of an array parameter according to its collection format
+func splitCollection(values []string, format string) []string {
+	sep := ","
+	switch format {
+	case "multi":
+		return values
+	case "ssv":
+		sep = " "
+	case "tsv":
+		sep = "\t"
+	case "pipes":
+		sep = "|"
+	}
+	var result []string
+	for _, value := range values {
+		result = append(result, strings.Split(value, sep)...)
+	}
+	return result
+}
+
+func (v *validator) formData(p Parameter, r *http.Request) ValidationErrors {
+	if v.formTooLarge {
+		return nil
+	}
+	if r.Form == nil && v.parseForm(r) {
+		v.formTooLarge = true
+		return ValidationErrors{v.tooLarge(p.In)}
+	}
+	if p.Type == types.File {
+		if r.MultipartForm == nil || len(r.MultipartForm.File[p.Name]) == 0 {
+			if p.Required {
+				return ValidationErrors{{In: p.In, Name: p.Name, Message: "is required"}}
+			}
+		}
+		return nil
+	}
+	values, ok := r.PostForm[p.Name]
+	return v.parameter(p, values, ok)
+}
+
+// parseForm parses the form of the request within the size limit and reports whether the limit is exceeded
+func (v *validator) parseForm(r *http.Request) bool {
+	var body *countingReader
+	if v.maxBodySize > 0 && r.Body != nil {
+		body = &countingReader{ReadCloser: http.MaxBytesReader(v.w, r.Body, v.maxBodySize)}
+		r.Body = body
+	}
+
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(32 << 20)
+	} else {
+		err = r.ParseForm()
+	}
+	return err != nil && body != nil && body.n >= v.maxBodySize
+}
+
+// countingReader counts the bytes read from the request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// tooLarge returns the violation of a request body over the size limit
+func (v *validator) tooLarge(in string) ValidationError {
+	return ValidationError{
+		In:      in,
+		Message: fmt.Sprintf("must not be larger than %d bytes", v.maxBodySize),
+		status:  http.StatusRequestEntityTooLarge,
+	}
+}
+
+func (v *validator) body(p Parameter, r *http.Request) ValidationErrors {
+	var data []byte
+	if r.Body != nil {
+		body := r.Body
+		if v.maxBodySize > 0 {
+			body = http.MaxBytesReader(v.w, r.Body, v.maxBodySize)
+		}
+		var err error
+		data, err = io.ReadAll(body)
+		_ = body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil && v.maxBodySize > 0 && int64(len(data)) >= v.maxBodySize {
+			return ValidationErrors{v.tooLarge(p.In)}
+		}
+		if err != nil {
+			return ValidationErrors{{In: p.In, Message: "read failed: " + err.Error()}}
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if p.Required {
+			return ValidationErrors{{In: p.In, Message: "is required"}}
+		}
+		return nil
+	}
+	if !isJSONMediaType(r.Header.Get("Content-Type")) {
+		return nil
+	}
+	return v.json(p.In, data, p.Schema)
+}
+
+// json checks the json encoded data against the schema
+func (v *validator) json(in string, data []byte, schema *Schema) ValidationErrors {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return ValidationErrors{{In: in, Message: "invalid json: " + err.Error()}}
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var errs ValidationErrors
+	report := func(name, msg string) {
+		errs = append(errs, ValidationError{In: in, Name: name, Message: msg})
+	}
+	v.schema(value, schema, "", report)
+	return errs
+}
+
+func (v *validator) schema(value interface{}, s *Schema, name string, report func(name, msg string)) {
+	if s.Ref != "" {
+		v.ref(value, s.Ref, name, report)
+		return
+	}
+	v.property(value, Property{Type: s.Type, Format: s.Format, Items: s.Items, AdditionalProperties: s.AdditionalProperties}, name, report)
+}
+
+func (v *validator) ref(value interface{}, ref string, name string, report func(name, msg string)) {
+	obj, ok := v.definitions[strings.TrimPrefix(ref, "#/definitions/")]
+	if !ok {
+		return
+	}
+	v.property(value, obj.property(), name, report)
+}
+
+// object checks the fields of a decoded json object against the required and described properties
+func (v *validator) object(m map[string]interface{}, required []string, properties map[string]Property,
+	name string, report func(name, msg string)) {
+	for _, key := range required {
+		if fv, ok := m[key]; !ok || fv == nil {
+			report(joinName(name, key), "is required")
+		}
+	}
+	for key, p := range properties {
+		if fv, ok := m[key]; ok && fv != nil {
+			v.property(fv, p, joinName(name, key), report)
+		}
+	}
+}
+
+func (v *validator) property(value interface{}, p Property, name string, report func(name, msg string)) {
+	if p.FreeForm {
+		v.oneOf(value, p.OneOf, name, report)
+		return
+	}
+	if p.Ref != "" {
+		v.ref(value, p.Ref, name, report)
+		return
+	}
+	v.typed(value, p.Type, p.Format, p.Enum, p.Items, name, report)
+
+	if list, ok := value.([]interface{}); ok {
+		if p.MinItems > 0 && len(list) < p.MinItems {
+			report(name, fmt.Sprintf("must have at least %d items", p.MinItems))
+		}
+		if p.MaxItems > 0 && len(list) > p.MaxItems {
+			report(name, fmt.Sprintf("must have at most %d items", p.MaxItems))
+		}
+	}
+	if n, ok := value.(json.Number); ok {
+		if msg := checkRange(p.Minimum, p.Maximum, n.String()); msg != "" {
+			report(name, msg)
+		}
+	}
+	if m, ok := value.(map[string]interface{}); ok {
+		v.object(m, p.Required, p.Properties, name, report)
+		if p.AdditionalProperties != nil {
+			for key, fv := range m {
+				if fv != nil {
+					v.property(fv, *p.AdditionalProperties, joinName(name, key), report)
+				}
+			}
+		}
+	}
+}
+
+// oneOf checks that the value matches one of the properties, if any
+func (v *validator) oneOf(value interface{}, properties []Property, name string, report func(name, msg string)) {
+	if len(properties) == 0 {
+		return
+	}
+	for _, p := range properties {
+		matched := true
+		v.property(value, p, name, func(string, string) {
+			matched = false
+		})
+		if matched {
+			return
+		}
+	}
+	report(name, "must match one of the allowed types")
+}
+
+func (v *validator) items(value interface{}, items *Items, name string, report func(name, msg string)) {
+	v.property(value, items.property(), name, report)
+}
+
+// typed checks the decoded json value against the type and enum, an empty type accepts any value
+func (v *validator) typed(value interface{}, typ, format string, enum []string, items *Items, name string, report func(name, msg string)) {
+	if value == nil {
+		return
+	}
+	switch typ {
+	case types.Array.String():
+		list, ok := value.([]interface{})
+		if !ok {
+			report(name, "must be an array")
+			return
+		}
+		if items == nil {
+			return
+		}
+		for i, item := range list {
+			if item != nil {
+				v.items(item, items, fmt.Sprintf("%s[%d]", name, i), report)
+			}
+		}
+		return
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			report(name, "must be an object")
+		}
+		return
+	case types.String.String():
+		s, ok := value.(string)
+		if !ok {
+			report(name, "must be a string")
+			return
+		}
+		if msg := checkEnum(enum, s); msg != "" {
+			report(name, msg)
+		}
+		return
+	case types.Boolean.String():
+		if _, ok := value.(bool); !ok {
+			report(name, "must be a boolean")
+		}
+		return
+	case types.Integer.String(), types.Number.String():
+		n, ok := value.(json.Number)
+		if !ok {
+			report(name, typeMessage(typ))
+			return
+		}
+		if msg := checkPrimitive(typ, n.String()); msg != "" {
+			report(name, msg)
+			return
+		}
+		if msg := checkEnum(enum, n.String()); msg != "" {
+			report(name, msg)
+		}
+	}
+}
+
+// checkPrimitive checks the string representation of a value against the primitive type
+func checkPrimitive(typ, value string) string {
+	var err error
+	switch typ {
+	case types.Integer.String():
+		_, err = strconv.ParseInt(value, 10, 64)
+	case types.Number.String():
+		_, err = strconv.ParseFloat(value, 64)
+	case types.Boolean.String():
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return typeMessage(typ)
+	}
+	return ""
+}
+
+// typeMessage returns the message of the violation of a value which is not of the type
+func typeMessage(typ string) string {
+	if typ == types.Integer.String() {
+		return "must be an integer"
+	}
+	return "must be a " + typ
+}
+
+// checkRange checks the string representation of a number against the inclusive bounds, if any
+func checkRange(minimum, maximum *float64, value string) string {
+	if minimum == nil && maximum == nil {
+		return ""
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ""
+	}
+	if minimum != nil && n < *minimum {
+		return "must be at least " + strconv.FormatFloat(*minimum, 'g', -1, 64)
+	}
+	if maximum != nil && n > *maximum {
+		return "must be at most " + strconv.FormatFloat(*maximum, 'g', -1, 64)
+	}
+	return ""
+}
+
+func checkEnum(enum []string, value string) string {
+	if len(enum) == 0 {
+		return ""
+	}
+	for _, v := range enum {
+		if v == value {
+			return ""
+		}
+	}
+	return "must be one of [" + strings.Join(enum, ", ") + "]"
+}
+
+func joinName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func isJSONMediaType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,276 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zc2638/swag/types"
+)
+
+type validatePet struct {
+	Name   string         `json:"name" required:""`
+	Status string         `json:"status" enum:"available,sold"`
+	Age    int            `json:"age"`
+	Owner  *Person        `json:"owner"`
+	Tags   []string       `json:"tags"`
+	Scores map[string]int `json:"scores"`
+	Extra  interface{}    `json:"extra" oneof:"validatePetExtra"`
+	Matrix [][2]int       `json:"matrix"`
+}
+
+func TestAPI_ValidateRequest(t *testing.T) {
+	RegisterOneOf("validatePetExtra", "", Person{})
+	e := &Endpoint{
+		Method: http.MethodPost,
+		Path:   "/pets/{id}",
+		Parameters: []Parameter{
+			{In: "path", Name: "id", Type: types.Integer, Required: true},
+			{In: "query", Name: "limit", Type: types.Integer},
+			{In: "query", Name: "sort", Type: types.String, Enum: []string{"asc", "desc"}},
+			{In: "header", Name: "X-Tenant", Type: types.String, Required: true},
+			{In: "body", Name: "body", Required: true, Schema: MakeSchema(validatePet{})},
+		},
+	}
+	api := New()
+	api.AddEndpoint(e)
+
+	tests := []struct {
+		name   string
+		query  string
+		header map[string]string
+		body   string
+		params map[string]string
+		want   ValidationErrors
+	}{
+		{
+			name:   "valid",
+			query:  "limit=10&sort=asc",
+			header: map[string]string{"X-Tenant": "zc"},
+			body:   `{"name":"kitty","status":"sold","age":2,"owner":{"First":"zc"},"tags":["a"],"scores":{"a":1},"extra":"a","matrix":[[1,2]]}`,
+			params: map[string]string{"id": "1"},
+		},
+		{
+			name:  "missing",
+			query: "limit=&sort=",
+			params: map[string]string{
+				"id": "",
+			},
+			want: ValidationErrors{
+				{In: "path", Name: "id", Message: "is required"},
+				{In: "query", Name: "sort", Message: "must be one of [asc, desc]"},
+				{In: "header", Name: "X-Tenant", Message: "is required"},
+				{In: "body", Message: "is required"},
+			},
+		},
+		{
+			name:   "invalid",
+			query:  "limit=ten",
+			header: map[string]string{"X-Tenant": "zc"},
+			body:   `{"status":"lost","age":"2","owner":{"First":1},"tags":[1],"scores":{"a":"1"},"extra":1,"matrix":[[1],["a",2]]}`,
+			params: map[string]string{"id": "a"},
+			want: ValidationErrors{
+				{In: "path", Name: "id", Message: "must be an integer"},
+				{In: "query", Name: "limit", Message: "must be an integer"},
+				{In: "body", Name: "name", Message: "is required"},
+				{In: "body", Name: "age", Message: "must be an integer"},
+				{In: "body", Name: "owner.First", Message: "must be a string"},
+				{In: "body", Name: "status", Message: "must be one of [available, sold]"},
+				{In: "body", Name: "tags[0]", Message: "must be a string"},
+				{In: "body", Name: "scores.a", Message: "must be an integer"},
+				{In: "body", Name: "extra", Message: "must match one of the allowed types"},
+				{In: "body", Name: "matrix[0]", Message: "must have at least 2 items"},
+				{In: "body", Name: "matrix[1][0]", Message: "must be an integer"},
+			},
+		},
+		{
+			name:   "invalid json",
+			header: map[string]string{"X-Tenant": "zc"},
+			body:   `{`,
+			want: ValidationErrors{
+				{In: "body", Message: "invalid json: unexpected EOF"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/pets/1?"+tt.query, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.params != nil {
+				r = r.WithContext(types.AddURLParamsToContext(r.Context(), tt.params))
+			}
+			assert.ElementsMatch(t, tt.want, api.ValidateRequest(e, r))
+		})
+	}
+}
+
+func TestAPI_ValidateRequests(t *testing.T) {
+	api := New()
+	api.AddEndpoint(&Endpoint{
+		Method: http.MethodPost,
+		Path:   "/pets",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		},
+		Parameters: []Parameter{
+			{In: "header", Name: "X-Tenant", Type: types.String, Required: true},
+			{In: "body", Name: "body", Required: true, Schema: MakeSchema(validatePet{})},
+		},
+	})
+	api.ValidateRequests()
+	es := api.Paths["/pets"]
+
+	body := `{"name":"kitty"}`
+	r := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+	r.Header.Set("X-Tenant", "zc")
+	w := httptest.NewRecorder()
+	es.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String(), "the body must still be readable by the handler")
+
+	r = httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	es.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var result struct {
+		Errors ValidationErrors `json:"errors"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.ElementsMatch(t, ValidationErrors{
+		{In: "header", Name: "X-Tenant", Message: "is required"},
+		{In: "body", Name: "name", Message: "is required"},
+	}, result.Errors)
+
+	api.SetMaxBodySize(8)
+	r = httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+	r.Header.Set("X-Tenant", "zc")
+	w = httptest.NewRecorder()
+	es.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	result.Errors = nil
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, ValidationErrors{{In: "body", Message: "must not be larger than 8 bytes"}}, result.Errors)
+}
+
+func TestAPI_ValidateRequestFormData(t *testing.T) {
+	e := &Endpoint{
+		Method: http.MethodPost,
+		Path:   "/upload",
+		Parameters: []Parameter{
+			{In: "formData", Name: "file", Type: types.File, Required: true},
+			{In: "formData", Name: "size", Type: types.Integer, Required: true},
+		},
+	}
+	api := New()
+	api.AddEndpoint(e)
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("size=big"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, ValidationErrors{
+		{In: "formData", Name: "file", Message: "is required"},
+		{In: "formData", Name: "size", Message: "must be an integer"},
+	}, api.ValidateRequest(e, r))
+
+	api.SetMaxBodySize(8)
+	handler := api.RequestValidation(e)(http.NotFoundHandler())
+	var data bytes.Buffer
+	form := multipart.NewWriter(&data)
+	_ = form.WriteField("size", "1")
+	_ = form.Close()
+	for contentType, body := range map[string]string{
+		"application/x-www-form-urlencoded": "size=123456789",
+		form.FormDataContentType():          data.String(),
+	} {
+		r = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, contentType)
+
+		var result struct {
+			Errors ValidationErrors `json:"errors"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, ValidationErrors{{In: "formData", Message: "must not be larger than 8 bytes"}}, result.Errors)
+	}
+}
+
+func TestAPI_ValidateResponse(t *testing.T) {
+	e := &Endpoint{
+		Method: http.MethodGet,
+		Path:   "/pets",
+		Responses: map[string]Response{
+			"200": {
+				Schema:  MakeSchema([]validatePet{}),
+				Headers: map[string]Header{"X-Total": {Type: types.Integer}},
+			},
+			"204": {},
+		},
+	}
+	api := New()
+	api.AddEndpoint(e)
+
+	header := http.Header{}
+	header.Set("X-Total", "1")
+	assert.Nil(t, api.ValidateResponse(e, http.StatusOK, header, []byte(`[{"name":"kitty"}]`)))
+	assert.Nil(t, api.ValidateResponse(e, http.StatusNoContent, http.Header{}, nil))
+	assert.Equal(t, ValidationErrors{
+		{In: "status", Name: "404", Message: "is not declared"},
+	}, api.ValidateResponse(e, http.StatusNotFound, http.Header{}, nil))
+	assert.Equal(t, ValidationErrors{
+		{In: "header", Name: "X-Total", Message: "is required"},
+		{In: "body", Name: "[0].name", Message: "is required"},
+	}, api.ValidateResponse(e, http.StatusOK, http.Header{}, []byte(`[{}]`)))
+}
+
+func TestAPI_ValidateResponses(t *testing.T) {
+	e := &Endpoint{
+		Method: http.MethodGet,
+		Path:   "/pets",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"name":1}`)
+		},
+		Responses: map[string]Response{
+			"200": {Schema: MakeSchema(validatePet{})},
+		},
+	}
+	api := New()
+	api.AddEndpoint(e)
+
+	var reported ValidationErrors
+	api.ValidateResponses(func(r *http.Request, e *Endpoint, errs ValidationErrors) {
+		reported = errs
+	})
+
+	w := httptest.NewRecorder()
+	api.Paths["/pets"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"name":1}`, w.Body.String())
+	assert.Equal(t, ValidationErrors{{In: "body", Name: "name", Message: "must be a string"}}, reported)
+}